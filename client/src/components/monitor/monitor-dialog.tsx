@@ -5,19 +5,19 @@ import { Switch } from "@/components/ui/switch";
 import { Tabs, TabsList, TabsTrigger } from "@/components/ui/tabs";
 import { ToggleGroup, ToggleGroupItem } from "@/components/ui/toggle-group";
 import {
-  DefaultErrorBodyTemplate,
-  DefaultErrorHeaderTemplate,
-  DefaultSuccessTemplate,
-  DefaultWarningTemplate,
-  MonitorForm
+  DefaultNotificationEvents,
+  MonitorForm,
+  NotificationChannel,
+  NotificationEvent,
+  NotificationEvents
 } from "@/types";
 import { ContainerConfig } from "./monitors/ContainerMonitor";
 import { HttpConfig } from "./monitors/HttpMonitor";
 import { PingConfig } from "./monitors/PingMonitor";
 import { TcpConfig } from "./monitors/TcpMonitor";
 import { XIcon } from "@phosphor-icons/react";
-import { Textarea } from "../ui/textarea";
-import { PostRequest } from "@/util";
+import { GetRequest } from "@/util";
+import { useEffect, useState } from "react";
 
 interface MonitorFormPanelProps {
   form: MonitorForm;
@@ -39,13 +39,35 @@ export function MonitorFormPanel({
   mode
 }: MonitorFormPanelProps) {
   const isCreateMode = mode === "create";
+  const [channels, setChannels] = useState<NotificationChannel[]>([]);
 
-  const getInitialNotificationTemplate = () => ({
-    errorTitle: DefaultErrorHeaderTemplate,
-    errorBody: DefaultErrorBodyTemplate,
-    successTitle: DefaultSuccessTemplate,
-    successBody: DefaultWarningTemplate
-  });
+  useEffect(() => {
+    (async () => {
+      const [code, response] = await GetRequest("notifications");
+      if (code !== 200) return;
+
+      const channels: NotificationChannel[] = response ?? [];
+      setChannels(channels);
+
+      // New monitors start out linked to the default channels
+      if (isCreateMode) {
+        onFormChange((prev) =>
+          prev.notifications
+            ? prev
+            : {
+                ...prev,
+                notifications: channels
+                  .filter((c) => c.default)
+                  .map((c) => ({
+                    notificationId: c.id!,
+                    events: DefaultNotificationEvents
+                  }))
+              }
+        );
+      }
+    })();
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [isCreateMode]);
 
   const getPlaceholder = () => {
     switch (form.connectionType) {
@@ -62,7 +84,7 @@ export function MonitorFormPanel({
     }
   };
 
-  const notification = form.notification || { enabled: false };
+  const links = form.notifications ?? [];
   const headers = form.headers || [];
 
   const handleFormChange = (
@@ -104,6 +126,32 @@ export function MonitorFormPanel({
     });
   };
 
+  const toggleChannel = (id: number, linked: boolean) => {
+    handleFormChange((prev) => {
+      const current = (prev.notifications ?? []).filter(
+        (l) => l.notificationId !== id
+      );
+      return {
+        ...prev,
+        notifications: linked
+          ? [
+              ...current,
+              { notificationId: id, events: DefaultNotificationEvents }
+            ]
+          : current
+      };
+    });
+  };
+
+  const updateEvents = (id: number, events: NotificationEvent[]) => {
+    handleFormChange((prev) => ({
+      ...prev,
+      notifications: (prev.notifications ?? []).map((l) =>
+        l.notificationId === id ? { ...l, events } : l
+      )
+    }));
+  };
+
   const renderTypeSpecificConfig = () => {
     switch (form.connectionType) {
       case "http":
@@ -128,26 +176,6 @@ export function MonitorFormPanel({
     }
   };
 
-  const handleTestCreateWebhook = async (type: "error" | "success") => {
-    const [code, response] = await PostRequest(
-      `webhook/test?type=${type}`,
-      {
-        name: form.name || "Test Monitor",
-        type: "webhook",
-        enabled: true,
-        status: "test",
-        message: "This is a test notification",
-        webhook: form.notification?.webhook,
-        template: form.notification?.template
-      },
-      false
-    );
-    if (code !== 200) {
-      const errorText = await response.text();
-      throw new Error(errorText || "Failed to create monitor");
-    }
-  };
-
   return (
     <div className="py-4 px-10 mx-auto">
       <div className="flex items-center justify-between">
@@ -299,282 +327,68 @@ export function MonitorFormPanel({
             </div>
           </div>
 
-          <div className="space-y-4">
-            <div>
-              <div className="flex items-center space-x-2">
-                <Switch
-                  id="monitor-notification"
-                  checked={notification.enabled}
-                  onCheckedChange={(checked) =>
-                    handleFormChange((prev) => ({
-                      ...prev,
-                      notification: checked
-                        ? {
-                            enabled: true,
-                            type: prev.notification?.type || "webhook",
-                            webhook: prev.notification?.webhook || "",
-                            email: prev.notification?.email || "",
-                            template:
-                              prev.notification?.template ||
-                              getInitialNotificationTemplate()
-                          }
-                        : { enabled: false }
-                    }))
-                  }
-                />
-                <Label
-                  htmlFor="monitor-notification"
-                  className="text-sm font-medium"
-                >
-                  Enable notifications
-                </Label>
-              </div>
-              <p className="text-muted-foreground text-sm mt-1">
-                Send a notification when the service is down
+          <div className="space-y-2">
+            <Label>Notifications</Label>
+            <p className="text-muted-foreground text-sm mt-1">
+              Channels notified about this monitor and the events they receive
+            </p>
+            {channels.length === 0 && (
+              <p className="text-muted-foreground text-sm">
+                No notification channels yet, add them from Channels in the
+                header
               </p>
-            </div>
-
-            {notification.enabled && (
-              <div className="space-y-4 pl-2 border-l-2 border-muted">
-                <div className="space-y-2">
-                  <Label>Notification method</Label>
-                  <ToggleGroup
-                    variant="outline"
-                    type="single"
-                    value={notification.type || "webhook"}
-                    onValueChange={(value) =>
-                      value &&
-                      handleFormChange((prev) => ({
-                        ...prev,
-                        notification: {
-                          ...prev.notification!,
-                          type: value as "webhook" | "email"
-                        }
-                      }))
-                    }
-                  >
-                    <ToggleGroupItem value="webhook">Webhook</ToggleGroupItem>
-                    <ToggleGroupItem value="email">Email</ToggleGroupItem>
-                  </ToggleGroup>
-                </div>
-
-                {notification.type === "webhook" ? (
-                  <div className="space-y-3">
-                    <div className="space-y-2">
-                      <Label htmlFor="monitor-webhook">Webhook URL</Label>
-                      <Input
-                        id="monitor-webhook"
-                        type="url"
-                        placeholder="https://discordapp.com/api/webhooks/..."
-                        value={notification.webhook || ""}
-                        onChange={(e) =>
-                          handleFormChange((prev) => ({
-                            ...prev,
-                            notification: {
-                              ...prev.notification!,
-                              webhook: e.target.value
-                            }
-                          }))
-                        }
-                      />
-                      <p className="text-xs text-muted-foreground">
-                        POST JSON payload will be sent on status changes.
-                      </p>
-                    </div>
-
-                    <div className="space-x-5">
-                      <Button
-                        type="button"
-                        variant="secondary"
-                        size="sm"
-                        disabled={
-                          !notification.webhook?.trim() ||
-                          !notification.webhook.startsWith("http")
-                        }
-                        onClick={async () => {
-                          handleTestCreateWebhook("error");
-                        }}
-                      >
-                        Test Error
-                      </Button>
-                      <Button
-                        type="button"
-                        variant="secondary"
-                        size="sm"
-                        disabled={
-                          !notification.webhook?.trim() ||
-                          !notification.webhook.startsWith("http")
-                        }
-                        onClick={async () => {
-                          handleTestCreateWebhook("success");
-                        }}
-                      >
-                        Test Recovery
-                      </Button>
-                      <p className="text-xs text-muted-foreground mt-1">
-                        Sends a test payload to visualize how the message would
-                        appear
-                      </p>
-                    </div>
-                  </div>
-                ) : (
-                  <div className="space-y-2">
-                    <Label htmlFor="monitor-email">Email address</Label>
-                    <Input
-                      id="monitor-email"
-                      type="email"
-                      placeholder="alerts@example.com"
-                      value={notification.email || ""}
-                      onChange={(e) =>
-                        handleFormChange((prev) => ({
-                          ...prev,
-                          notification: {
-                            ...prev.notification!,
-                            email: e.target.value
-                          }
-                        }))
-                      }
-                    />
-                    <p className="text-xs text-muted-foreground">
-                      An email will be sent on status changes.
-                    </p>
-                  </div>
-                )}
-                <div className="space-y-4">
-                  <Label>Message Templates</Label>
-                  <p className="text-muted-foreground text-sm">
-                    Customize notification messages using template variables
-                  </p>
-
-                  <div className="space-y-2">
-                    <Label htmlFor="error-title">Error Alert - Title</Label>
-                    <Input
-                      id="error-title"
-                      value={
-                        notification.template?.errorTitle || "{{.Name}} is down"
-                      }
-                      onChange={(e) =>
-                        handleFormChange((prev) => ({
-                          ...prev,
-                          notification: {
-                            ...prev.notification!,
-                            template: {
-                              errorTitle: e.target.value,
-                              errorBody:
-                                prev.notification?.template?.errorBody ||
-                                DefaultErrorBodyTemplate,
-                              successTitle:
-                                prev.notification?.template?.successTitle ||
-                                DefaultSuccessTemplate,
-                              successBody:
-                                prev.notification?.template?.successBody ||
-                                DefaultWarningTemplate
-                            }
-                          }
-                        }))
-                      }
-                    />
-                  </div>
-
-                  <div className="space-y-2">
-                    <Label htmlFor="error-body">Error Alert - Body</Label>
-                    <Textarea
-                      id="error-body"
-                      value={
-                        notification.template?.errorBody ||
-                        "The goose encountered an issue while contacting {{.Name}} at {{.Timestamp}}"
-                      }
-                      onChange={(e) =>
-                        handleFormChange((prev) => ({
-                          ...prev,
-                          notification: {
-                            ...prev.notification!,
-                            template: {
-                              errorTitle:
-                                prev.notification?.template?.errorTitle ||
-                                DefaultErrorHeaderTemplate,
-                              errorBody: e.target.value,
-                              successTitle:
-                                prev.notification?.template?.successTitle ||
-                                DefaultSuccessTemplate,
-                              successBody:
-                                prev.notification?.template?.successBody ||
-                                DefaultWarningTemplate
-                            }
-                          }
-                        }))
+            )}
+            {channels.map((channel) => {
+              const link = links.find(
+                (l) => l.notificationId === channel.id
+              );
+              return (
+                <div
+                  key={channel.id}
+                  className="space-y-2 pl-2 border-l-2 border-muted"
+                >
+                  <div className="flex items-center space-x-2">
+                    <Switch
+                      id={`monitor-channel-${channel.id}`}
+                      checked={!!link}
+                      onCheckedChange={(checked) =>
+                        toggleChannel(channel.id!, checked)
                       }
-                      rows={3}
                     />
-                  </div>
-
-                  <div className="space-y-2">
-                    <Label htmlFor="success-title">
-                      Recovery Alert - Title
+                    <Label
+                      htmlFor={`monitor-channel-${channel.id}`}
+                      className="text-sm font-medium"
+                    >
+                      {channel.name}
                     </Label>
-                    <Input
-                      id="success-title"
-                      value={
-                        notification.template?.successTitle ||
-                        "{{.Name}} is back online"
-                      }
-                      onChange={(e) =>
-                        handleFormChange((prev) => ({
-                          ...prev,
-                          notification: {
-                            ...prev.notification!,
-                            template: {
-                              errorTitle:
-                                prev.notification?.template?.errorTitle ||
-                                DefaultErrorHeaderTemplate,
-                              errorBody:
-                                prev.notification?.template?.errorBody ||
-                                DefaultErrorBodyTemplate,
-                              successTitle: e.target.value,
-                              successBody:
-                                prev.notification?.template?.successBody ||
-                                DefaultWarningTemplate
-                            }
-                          }
-                        }))
-                      }
-                    />
+                    <span className="text-xs text-muted-foreground">
+                      {channel.type}
+                      {!channel.enabled && ", disabled"}
+                    </span>
                   </div>
-
-                  <Label htmlFor="success-body">Recovery Alert - Body</Label>
-                  <div className="space-y-2">
-                    <Textarea
-                      id="success-body"
-                      value={
-                        notification.template?.successBody ||
-                        "Good news! {{.Name}} has recovered and is responding normally."
+                  {link && (
+                    <ToggleGroup
+                      variant="outline"
+                      type="multiple"
+                      size="sm"
+                      value={link.events}
+                      onValueChange={(events) =>
+                        updateEvents(
+                          channel.id!,
+                          events as NotificationEvent[]
+                        )
                       }
-                      onChange={(e) =>
-                        handleFormChange((prev) => ({
-                          ...prev,
-                          notification: {
-                            ...prev.notification!,
-                            template: {
-                              errorTitle:
-                                prev.notification?.template?.errorTitle ||
-                                DefaultErrorHeaderTemplate,
-                              errorBody:
-                                prev.notification?.template?.errorBody ||
-                                DefaultErrorBodyTemplate,
-                              successTitle:
-                                prev.notification?.template?.successTitle ||
-                                DefaultSuccessTemplate,
-                              successBody: e.target.value
-                            }
-                          }
-                        }))
-                      }
-                      rows={3}
-                    />
-                  </div>
+                    >
+                      {NotificationEvents.map((event) => (
+                        <ToggleGroupItem key={event} value={event}>
+                          {event}
+                        </ToggleGroupItem>
+                      ))}
+                    </ToggleGroup>
+                  )}
                 </div>
-              </div>
-            )}
+              );
+            })}
           </div>
         </div>
 
@@ -0,0 +1,491 @@
+import { Badge } from "@/components/ui/badge";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import { Textarea } from "@/components/ui/textarea";
+import { ToggleGroup, ToggleGroupItem } from "@/components/ui/toggle-group";
+import {
+  DefaultNotificationChannel,
+  NotificationChannel,
+  Template
+} from "@/types";
+import {
+  DeleteRequest,
+  detectPlatform,
+  GetRequest,
+  PostRequest,
+  PutRequest
+} from "@/util";
+import { PlusIcon, TrashIcon, XIcon } from "@phosphor-icons/react";
+import { useCallback, useEffect, useState } from "react";
+import { toast } from "sonner";
+
+interface ChannelPanelProps {
+  onClose: () => void;
+}
+
+// Headers are edited as one "Key: Value" pair per line
+const headersToText = (headers: Record<string, string> | null) =>
+  Object.entries(headers ?? {})
+    .map(([key, value]) => `${key}: ${value}`)
+    .join("\n");
+
+const textToHeaders = (text: string) => {
+  const headers: Record<string, string> = {};
+  for (const line of text.split("\n")) {
+    const index = line.indexOf(":");
+    if (index <= 0) continue;
+    headers[line.slice(0, index).trim()] = line.slice(index + 1).trim();
+  }
+  return headers;
+};
+
+export function ChannelPanel({ onClose }: ChannelPanelProps) {
+  const [channels, setChannels] = useState<NotificationChannel[]>([]);
+  const [platforms, setPlatforms] = useState<string[]>([]);
+  const [form, setForm] = useState<NotificationChannel | null>(null);
+  const [headersText, setHeadersText] = useState("");
+  const [isSubmitting, setIsSubmitting] = useState(false);
+
+  const loadChannels = useCallback(async () => {
+    const [code, response] = await GetRequest("notifications");
+    if (code !== 200) {
+      toast.error("Unable to fetch notification channels");
+      return;
+    }
+    setChannels(response ?? []);
+  }, []);
+
+  useEffect(() => {
+    loadChannels();
+
+    (async () => {
+      const [code, response] = await GetRequest("notifications/platforms");
+      if (code === 200) {
+        setPlatforms(response.platforms ?? []);
+      }
+    })();
+  }, [loadChannels]);
+
+  const isEmail = form?.type === "email";
+  const isGenericWebhook = form?.type === "webhook";
+
+  const editChannel = (channel: NotificationChannel) => {
+    setForm({
+      ...DefaultNotificationChannel,
+      ...channel,
+      webhookOptions: {
+        ...DefaultNotificationChannel.webhookOptions,
+        ...channel.webhookOptions,
+        secret: ""
+      }
+    });
+    setHeadersText(headersToText(channel.webhookOptions?.headers ?? null));
+  };
+
+  const createChannel = () => {
+    setForm(DefaultNotificationChannel);
+    setHeadersText("");
+  };
+
+  const updateForm = (changes: Partial<NotificationChannel>) => {
+    setForm((prev) => (prev ? { ...prev, ...changes } : prev));
+  };
+
+  const updateTemplate = (field: keyof Template, value: string) => {
+    setForm((prev) =>
+      prev ? { ...prev, template: { ...prev.template, [field]: value } } : prev
+    );
+  };
+
+  const updateWebhookOptions = (
+    changes: Partial<NotificationChannel["webhookOptions"]>
+  ) => {
+    setForm((prev) =>
+      prev
+        ? { ...prev, webhookOptions: { ...prev.webhookOptions, ...changes } }
+        : prev
+    );
+  };
+
+  const payload = () => ({
+    ...form,
+    webhookOptions: {
+      ...form!.webhookOptions,
+      headers: textToHeaders(headersText)
+    }
+  });
+
+  const handleSave = async () => {
+    if (!form) return;
+    setIsSubmitting(true);
+
+    const [code] = form.id
+      ? await PutRequest(`notifications/${form.id}`, payload())
+      : await PostRequest("notifications", payload());
+    setIsSubmitting(false);
+
+    if (code !== 200) return;
+    toast.success(`Channel "${form.name}" saved`);
+    setForm(null);
+    await loadChannels();
+  };
+
+  const handleDelete = async (channel: NotificationChannel) => {
+    const [code] = await DeleteRequest(`notifications/${channel.id}`, null);
+    if (code !== 200) return;
+
+    toast.success(`Channel "${channel.name}" deleted`);
+    if (form?.id === channel.id) setForm(null);
+    await loadChannels();
+  };
+
+  const handleTest = async (type: "error" | "success") => {
+    const [code] = await PostRequest(`webhook/test?type=${type}`, payload());
+    if (code === 200) {
+      toast.success("Test notification sent");
+    }
+  };
+
+  return (
+    <div className="py-4 px-10 mx-auto">
+      <div className="flex items-center justify-between">
+        <div>
+          <h2 className="text-2xl font-bold tracking-tight">
+            Notification Channels
+          </h2>
+          <p className="text-muted-foreground text-sm mt-1">
+            Channels receive the events of the monitors linked to them.
+          </p>
+        </div>
+        <Button variant="ghost" size="icon" onClick={onClose}>
+          <XIcon className="h-5 w-5" />
+        </Button>
+      </div>
+
+      <div className="grid grid-cols-1 md:grid-cols-2 gap-8 mt-6">
+        <div className="space-y-2">
+          <div className="flex items-center justify-between">
+            <Label>Channels</Label>
+            <Button size="sm" onClick={createChannel}>
+              <PlusIcon className="h-4 w-4 mr-2" />
+              Add
+            </Button>
+          </div>
+
+          {channels.length === 0 && (
+            <p className="text-muted-foreground text-sm">
+              No channels yet, add one to start sending notifications.
+            </p>
+          )}
+
+          {channels.map((channel) => (
+            <div
+              key={channel.id}
+              className={`flex items-center justify-between border p-2 hover:border-primary transition-colors cursor-pointer ${
+                form?.id === channel.id ? "border-primary" : ""
+              }`}
+              onClick={() => editChannel(channel)}
+            >
+              <div>
+                <p className="font-medium">{channel.name}</p>
+                <p className="truncate text-sm text-muted-foreground max-w-64">
+                  {channel.type === "email"
+                    ? channel.email
+                    : channel.webhookHost}
+                </p>
+              </div>
+              <div className="flex items-center gap-2">
+                <Badge variant="outline">{channel.type}</Badge>
+                {channel.default && <Badge>default</Badge>}
+                {!channel.enabled && (
+                  <Badge variant="secondary">disabled</Badge>
+                )}
+                <Button
+                  variant="ghost"
+                  size="icon"
+                  onClick={(e) => {
+                    e.stopPropagation();
+                    handleDelete(channel);
+                  }}
+                >
+                  <TrashIcon className="h-4 w-4" />
+                </Button>
+              </div>
+            </div>
+          ))}
+        </div>
+
+        {form && (
+          <div className="space-y-4">
+            <div className="space-y-2">
+              <Label htmlFor="channel-name">Name</Label>
+              <Input
+                id="channel-name"
+                placeholder="On-call"
+                value={form.name}
+                onChange={(e) => updateForm({ name: e.target.value })}
+              />
+            </div>
+
+            <div className="flex gap-6">
+              <div className="flex items-center space-x-2">
+                <Switch
+                  id="channel-enabled"
+                  checked={form.enabled}
+                  onCheckedChange={(checked) =>
+                    updateForm({ enabled: checked })
+                  }
+                />
+                <Label htmlFor="channel-enabled">Enabled</Label>
+              </div>
+              <div className="flex items-center space-x-2">
+                <Switch
+                  id="channel-default"
+                  checked={form.default}
+                  onCheckedChange={(checked) =>
+                    updateForm({ default: checked })
+                  }
+                />
+                <Label htmlFor="channel-default">Link to new monitors</Label>
+              </div>
+            </div>
+
+            <div className="space-y-2">
+              <Label>Platform</Label>
+              <ToggleGroup
+                variant="outline"
+                type="single"
+                value={form.type}
+                onValueChange={(value) => value && updateForm({ type: value })}
+              >
+                {platforms.map((platform) => (
+                  <ToggleGroupItem key={platform} value={platform}>
+                    {platform}
+                  </ToggleGroupItem>
+                ))}
+              </ToggleGroup>
+            </div>
+
+            {isEmail ? (
+              <div className="space-y-2">
+                <Label htmlFor="channel-email">Email addresses</Label>
+                <Input
+                  id="channel-email"
+                  placeholder="alerts@example.com, oncall@example.com"
+                  value={form.email}
+                  onChange={(e) => updateForm({ email: e.target.value })}
+                />
+              </div>
+            ) : (
+              <div className="space-y-2">
+                <Label htmlFor="channel-webhook">Webhook URL</Label>
+                <Input
+                  id="channel-webhook"
+                  type="url"
+                  placeholder={
+                    form.webhookHost
+                      ? `Unchanged (${form.webhookHost})`
+                      : "https://discordapp.com/api/webhooks/..."
+                  }
+                  value={form.webhook}
+                  onChange={(e) =>
+                    updateForm({
+                      webhook: e.target.value,
+                      // Only guess the platform for new channels
+                      type: form.id ? form.type : detectPlatform(e.target.value)
+                    })
+                  }
+                />
+                {form.id && form.webhook && (
+                  <p className="text-xs text-muted-foreground">
+                    A new URL needs the secret and header values again, stored
+                    ones are only sent to the current URL.
+                  </p>
+                )}
+              </div>
+            )}
+
+            {isGenericWebhook && (
+              <div className="space-y-4 pl-2 border-l-2 border-muted">
+                <div className="space-y-2">
+                  <Label>Method</Label>
+                  <ToggleGroup
+                    variant="outline"
+                    type="single"
+                    value={form.webhookOptions.method || "POST"}
+                    onValueChange={(value) =>
+                      value && updateWebhookOptions({ method: value })
+                    }
+                  >
+                    <ToggleGroupItem value="POST">POST</ToggleGroupItem>
+                    <ToggleGroupItem value="PUT">PUT</ToggleGroupItem>
+                    <ToggleGroupItem value="PATCH">PATCH</ToggleGroupItem>
+                  </ToggleGroup>
+                </div>
+
+                <div className="space-y-2">
+                  <Label htmlFor="channel-headers">Headers</Label>
+                  <Textarea
+                    id="channel-headers"
+                    placeholder="Authorization: Bearer ..."
+                    value={headersText}
+                    onChange={(e) => setHeadersText(e.target.value)}
+                    rows={3}
+                  />
+                  <p className="text-xs text-muted-foreground">
+                    One header per line, as Key: Value. Stored values are not
+                    shown, leave them empty to keep them.
+                  </p>
+                </div>
+
+                <div className="space-y-2">
+                  <Label htmlFor="channel-body">Body template (optional)</Label>
+                  <Textarea
+                    id="channel-body"
+                    placeholder='{"text": {{json .Title}}}'
+                    value={form.webhookOptions.body}
+                    onChange={(e) =>
+                      updateWebhookOptions({ body: e.target.value })
+                    }
+                    rows={3}
+                  />
+                </div>
+
+                <div className="space-y-2">
+                  <Label htmlFor="channel-secret">Signing secret</Label>
+                  <Input
+                    id="channel-secret"
+                    type="password"
+                    autoComplete="new-password"
+                    placeholder={
+                      form.webhookOptions.hasSecret &&
+                      !form.webhookOptions.clearSecret &&
+                      !form.webhook
+                        ? "Unchanged"
+                        : "None"
+                    }
+                    value={form.webhookOptions.secret ?? ""}
+                    onChange={(e) =>
+                      updateWebhookOptions({
+                        secret: e.target.value,
+                        clearSecret: false
+                      })
+                    }
+                  />
+                  {form.webhookOptions.hasSecret && (
+                    <div className="flex items-center space-x-2">
+                      <Switch
+                        id="channel-clear-secret"
+                        checked={form.webhookOptions.clearSecret ?? false}
+                        onCheckedChange={(checked) =>
+                          updateWebhookOptions({
+                            clearSecret: checked,
+                            secret: ""
+                          })
+                        }
+                      />
+                      <Label htmlFor="channel-clear-secret">
+                        Remove the stored secret
+                      </Label>
+                    </div>
+                  )}
+                </div>
+              </div>
+            )}
+
+            <div className="space-y-4">
+              <Label>Message Templates</Label>
+              <p className="text-muted-foreground text-sm">
+                Customize notification messages using template variables
+              </p>
+
+              <div className="space-y-2">
+                <Label htmlFor="error-title">Error Alert - Title</Label>
+                <Input
+                  id="error-title"
+                  value={form.template.errorTitle}
+                  onChange={(e) => updateTemplate("errorTitle", e.target.value)}
+                />
+              </div>
+
+              <div className="space-y-2">
+                <Label htmlFor="error-body">Error Alert - Body</Label>
+                <Textarea
+                  id="error-body"
+                  value={form.template.errorBody}
+                  onChange={(e) => updateTemplate("errorBody", e.target.value)}
+                  rows={3}
+                />
+              </div>
+
+              <div className="space-y-2">
+                <Label htmlFor="success-title">Recovery Alert - Title</Label>
+                <Input
+                  id="success-title"
+                  value={form.template.successTitle}
+                  onChange={(e) =>
+                    updateTemplate("successTitle", e.target.value)
+                  }
+                />
+              </div>
+
+              <div className="space-y-2">
+                <Label htmlFor="success-body">Recovery Alert - Body</Label>
+                <Textarea
+                  id="success-body"
+                  value={form.template.successBody}
+                  onChange={(e) =>
+                    updateTemplate("successBody", e.target.value)
+                  }
+                  rows={3}
+                />
+              </div>
+            </div>
+
+            <div className="space-x-5">
+              <Button
+                type="button"
+                variant="secondary"
+                size="sm"
+                onClick={() => handleTest("error")}
+              >
+                Test Error
+              </Button>
+              <Button
+                type="button"
+                variant="secondary"
+                size="sm"
+                onClick={() => handleTest("success")}
+              >
+                Test Recovery
+              </Button>
+              <p className="text-xs text-muted-foreground mt-1">
+                Sends a test payload to visualize how the message would appear
+              </p>
+            </div>
+
+            <div className="pt-6 border-t flex justify-end gap-3">
+              <Button
+                type="button"
+                variant="outline"
+                onClick={() => setForm(null)}
+                disabled={isSubmitting}
+              >
+                Cancel
+              </Button>
+              <Button onClick={handleSave} disabled={isSubmitting}>
+                {isSubmitting
+                  ? "Saving..."
+                  : form.id
+                    ? "Save Changes"
+                    : "Add Channel"}
+              </Button>
+            </div>
+          </div>
+        )}
+      </div>
+    </div>
+  );
+}
@@ -1,7 +1,13 @@
+import { BellIcon } from "@phosphor-icons/react";
 import { ModeToggle } from "./theme/theme-toggle";
+import { Button } from "./ui/button";
 import { NavActions } from "./ui/nav-actions";
 
-export function SiteHeader() {
+interface SiteHeaderProps {
+  onOpenChannels?: () => void;
+}
+
+export function SiteHeader({ onOpenChannels }: SiteHeaderProps) {
   return (
     <header className="flex h-12 shrink-0 items-center gap-2 border-b bg-background/95 backdrop-blur supports-backdrop-filter:bg-background/60 transition-[width,height] ease-linear group-has-data-[collapsible=icon]/sidebar-wrapper:h-12">
       <div className="flex w-full items-center gap-2 px-4 lg:gap-3 lg:px-6">
@@ -12,6 +18,12 @@ export function SiteHeader() {
       </div>
 
       <div className="flex items-center gap-2 px-4 lg:px-6">
+        {onOpenChannels && (
+          <Button variant="ghost" size="sm" onClick={onOpenChannels}>
+            <BellIcon className="h-4 w-4 mr-2" />
+            Channels
+          </Button>
+        )}
         <ModeToggle />
         <NavActions />
       </div>
@@ -22,12 +22,18 @@ import {
   SidebarMenuButton,
   SidebarMenuItem
 } from "@/components/ui/sidebar";
-import { GetRequest } from "@/util";
+import { GetRequest, PostRequest } from "@/util";
 import { DotsThreeOutlineIcon, GithubLogoIcon } from "@phosphor-icons/react";
 import { JSX, useEffect, useState } from "react";
 import { toast } from "sonner";
 
-const data = [
+interface NavItem {
+  label: string;
+  dialog?: () => JSX.Element;
+  onSelect?: () => void;
+}
+
+const data: NavItem[][] = [
   [
     {
       label: "About",
@@ -36,6 +42,11 @@ const data = [
   ]
 ];
 
+async function logout() {
+  await PostRequest("auth/logout", {}, true, true);
+  document.location.href = "/login";
+}
+
 interface Info {
   version: string;
   commit: string;
@@ -119,6 +130,22 @@ export function NavActions() {
     null | ((props: { onClose: () => void }) => JSX.Element)
   >(null);
 
+  const [username, setUsername] = useState<string | null>(null);
+
+  // Without authentication there is no user and nothing to log out of
+  useEffect(() => {
+    (async () => {
+      const [code, user] = await GetRequest("auth/me", true);
+      if (code === 200 && user?.username) {
+        setUsername(user.username);
+      }
+    })();
+  }, []);
+
+  const groups = username
+    ? [...data, [{ label: `Log out ${username}`, onSelect: logout }]]
+    : data;
+
   const closeDialog = () => {
     setDialogComponent(null);
   };
@@ -141,7 +168,7 @@ export function NavActions() {
         >
           <Sidebar collapsible="none" className="bg-transparent">
             <SidebarContent>
-              {data.map((group, index) => (
+              {groups.map((group, index) => (
                 <SidebarGroup key={index} className="border-b last:border-none">
                   <SidebarGroupContent className="gap-0">
                     <SidebarMenu>
@@ -151,7 +178,11 @@ export function NavActions() {
                             className="cursor-pointer"
                             onClick={() => {
                               setIsOpen(false);
-                              setDialogComponent(() => item.dialog);
+                              if (item.onSelect) {
+                                item.onSelect();
+                              } else if (item.dialog) {
+                                setDialogComponent(() => item.dialog!);
+                              }
                             }}
                           >
                             <span>{item.label}</span>
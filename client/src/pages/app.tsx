@@ -4,6 +4,7 @@ import { Route, Routes, useLocation } from "react-router-dom";
 import Layout from "../app/layout";
 import { FileXIcon } from "@phosphor-icons/react";
 import Home from "./home";
+import Login from "./login";
 import { ThemeProvider } from "@/components/theme/theme-provider";
 
 function NotFound() {
@@ -31,6 +32,7 @@ function App() {
     <ThemeProvider defaultTheme="dark" storageKey="vite-ui-theme">
       <AnimatePresence mode="wait">
         <Routes location={location} key={location.pathname}>
+          <Route path="/login" element={<Login />} />
           <Route element={<Layout />}>
             <Route path="/" element={<Home />} />
             <Route path="/home" element={<Home />} />
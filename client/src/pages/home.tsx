@@ -9,20 +9,17 @@ import { mapApiMonitor } from "@/lib/monitors";
 import { useMonitorPolling } from "@/hooks/useMonitorPolling";
 import { MonitorSidebar } from "@/components/monitor/monitor-sidebar";
 import {
-  DefaultErrorBodyTemplate,
-  DefaultErrorHeaderTemplate,
   DefaultMonitorForm,
-  DefaultSuccessTemplate,
-  DefaultWarningTemplate,
   Monitor,
   MonitorForm
 } from "@/types";
 import { MonitorDetail } from "@/components/monitor/monitor-detail";
 import { MonitorFormPanel } from "@/components/monitor/monitor-dialog";
+import { ChannelPanel } from "@/components/notification/channel-panel";
 
 const SELECTED_MONITOR_KEY = "selectedMonitorId";
 
-type ViewMode = "detail" | "create" | "edit";
+type ViewMode = "detail" | "create" | "edit" | "channels";
 
 export default function Home() {
   const [monitors, setMonitors] = useState<Monitor[]>([]);
@@ -32,7 +29,7 @@ export default function Home() {
   const [form, setForm] = useState<MonitorForm>(DefaultMonitorForm);
 
   const refreshMonitor = useCallback(async (id: number) => {
-    const [code, response] = await GetRequest(`monitor/${id}`);
+    const [code, response] = await GetRequest(`monitor/${id}?checks=100`);
     if (code !== 200) return;
 
     setMonitors((prev) => prev.map((m) => (m.id === id ? response : m)));
@@ -111,55 +108,17 @@ export default function Home() {
       interval: monitor.interval,
       alwaysSave: monitor.alwaysSave,
       headers: monitor.headers ?? [],
-      notification: monitor.notification
-        ? {
-            ...monitor.notification,
-            template: {
-              errorTitle:
-                monitor.notification.template?.errorTitle ||
-                DefaultErrorHeaderTemplate,
-              errorBody:
-                monitor.notification.template?.errorBody ||
-                DefaultErrorBodyTemplate,
-              successTitle:
-                monitor.notification.template?.successTitle ||
-                DefaultSuccessTemplate,
-              successBody:
-                monitor.notification.template?.successBody ||
-                DefaultWarningTemplate
-            }
-          }
-        : null
+      notifications: monitor.notifications ?? []
     });
     setViewMode("edit");
   };
 
-  const prepareNotificationForPayload = (
-    notification: Notification | null | undefined
-  ) => {
-    if (!notification || !notification.enabled) return null;
-
-    return {
-      ...notification,
-      template: {
-        errorTitle:
-          notification.template?.errorTitle || DefaultErrorHeaderTemplate,
-        errorBody: notification.template?.errorBody || DefaultErrorBodyTemplate,
-        successTitle:
-          notification.template?.successTitle || DefaultSuccessTemplate,
-        successBody:
-          notification.template?.successBody || DefaultWarningTemplate
-      }
-    };
-  };
-
   const handleCreate = async () => {
     setIsSubmitting(true);
     try {
       const payload = {
         ...form,
-        headers: sanitizeHeaders(form.headers),
-        notification: prepareNotificationForPayload(form.notification)
+        headers: sanitizeHeaders(form.headers)
       };
 
       const [code, response] = await PostRequest("monitor", payload);
@@ -200,8 +159,7 @@ export default function Home() {
     try {
       const payload = {
         ...form,
-        headers: sanitizeHeaders(form.headers),
-        notification: prepareNotificationForPayload(form.notification)
+        headers: sanitizeHeaders(form.headers)
       };
 
       const [code, response] = await PutRequest(
@@ -252,7 +210,7 @@ export default function Home() {
 
   return (
     <div className="flex h-screen flex-col">
-      <SiteHeader />
+      <SiteHeader onOpenChannels={() => setViewMode("channels")} />
 
       <div className="flex flex-1 overflow-hidden">
         <MonitorSidebar
@@ -263,7 +221,9 @@ export default function Home() {
         />
 
         <main className="flex-1 overflow-y-auto">
-          {viewMode === "create" || viewMode === "edit" ? (
+          {viewMode === "channels" ? (
+            <ChannelPanel onClose={handleCancel} />
+          ) : viewMode === "create" || viewMode === "edit" ? (
             <MonitorFormPanel
               form={form}
               onFormChange={setForm}
@@ -16,7 +16,7 @@ export type MonitorForm = {
   interval: number;
   alwaysSave: boolean;
   headers: Headers[];
-  notification?: Notification | null;
+  notifications?: NotificationLink[];
 };
 
 export const DefaultMonitorForm: MonitorForm = {
@@ -29,18 +29,54 @@ export const DefaultMonitorForm: MonitorForm = {
   body: "",
   interval: 60,
   alwaysSave: false,
-  headers: [],
-  notification: null
+  headers: []
 };
 
-interface Notification {
-  id: number;
-  monitorID: number;
+export type NotificationEvent = "down" | "up" | "reminder" | "degraded";
+
+export const NotificationEvents: NotificationEvent[] = [
+  "down",
+  "up",
+  "reminder",
+  "degraded"
+];
+
+// Events sent over links created without a selection, same as the server
+export const DefaultNotificationEvents: NotificationEvent[] = [
+  "down",
+  "up",
+  "reminder"
+];
+
+export interface NotificationLink {
+  notificationId: number;
+  events: NotificationEvent[];
+}
+
+export interface WebhookOptions {
+  method: string;
+  headers: Record<string, string> | null;
+  body: string;
+  hasSecret?: boolean;
+  secret?: string;
+  clearSecret?: boolean;
+}
+
+export interface NotificationChannel {
+  id?: number;
+  name: string;
   enabled: boolean;
-  type: "webhook" | "email";
+  default: boolean;
+  type: string;
+  // Webhook URLs are never returned, only their host
   webhook: string;
+  webhookHost?: string;
   email: string;
   template: Template;
+  webhookOptions: WebhookOptions;
+  // Null for channels shared with every team
+  teamId?: number | null;
+  createdAt?: string;
 }
 
 export const DefaultErrorHeaderTemplate = "{{.Name}} is down";
@@ -49,7 +85,27 @@ export const DefaultErrorBodyTemplate =
 export const DefaultSuccessTemplate = "{{.Name}} is back online";
 export const DefaultWarningTemplate =
   "Good news! {{.Name}} has recovered and is responding normally.";
-interface Template {
+
+export const DefaultNotificationChannel: NotificationChannel = {
+  name: "",
+  enabled: true,
+  default: false,
+  type: "webhook",
+  webhook: "",
+  email: "",
+  template: {
+    errorTitle: DefaultErrorHeaderTemplate,
+    errorBody: DefaultErrorBodyTemplate,
+    successTitle: DefaultSuccessTemplate,
+    successBody: DefaultWarningTemplate
+  },
+  webhookOptions: {
+    method: "POST",
+    headers: null,
+    body: ""
+  }
+};
+export interface Template {
   errorTitle: string;
   errorBody: string;
   successTitle: string;
@@ -79,7 +135,7 @@ export interface Monitor {
   checked: string;
   checks: Check[];
   result: string;
-  notification: Notification | null;
+  notifications: NotificationLink[] | null;
   totalChecks: number;
   successfulChecks: number;
   headers: Headers[];
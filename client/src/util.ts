@@ -32,7 +32,7 @@ const showToast = (message: string, id: string) => {
 };
 
 async function isAuthenticated(res: Response) {
-  if (res.status === 401) {
+  if (res.status === 401 && document.location.pathname !== "/login") {
     document.location.href = "/login";
   }
 }
@@ -210,3 +210,13 @@ export async function DeleteRequest(
     return [500, null];
   }
 }
+
+// Guesses the notification platform of a webhook URL to preselect it, the
+// server only uses the platform it is given.
+export function detectPlatform(url: string | undefined) {
+  const lower = (url || "").toLowerCase();
+  if (lower.includes("discord")) return "discord";
+  if (lower.includes("slack")) return "slack";
+  if (lower.includes("teams") || lower.includes("office.com")) return "teams";
+  return "webhook";
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"honk/internal/auth"
+	"honk/internal/database"
+	"honk/internal/monitor"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sessionCookie   = "honk_session"
+	userContextKey  = "user"
+	tokenContextKey = "token"
+)
+
+func (api *API) setupAuth() {
+	api.public.POST("/auth/login", api.login)
+	api.public.POST("/auth/logout", api.logout)
+
+	api.routes.GET("/auth/me", api.getCurrentUser)
+	api.routes.POST("/auth/password", api.changePassword)
+
+	api.routes.GET("/auth/tokens", api.listTokens)
+	api.routes.POST("/auth/tokens", api.createToken)
+	api.routes.DELETE("/auth/tokens/:id", api.revokeToken)
+
+	api.registerUserRoutes()
+}
+
+// authMiddleware rejects requests without a valid API token or session
+// cookie, or whose user lacks the role the route requires. API tokens are
+// also limited to the scope the route requires.
+func (api *API) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if bearer, ok := bearerToken(c); ok {
+			api.authenticateToken(c, bearer)
+			return
+		}
+
+		token, _ := c.Cookie(sessionCookie)
+
+		user, err := api.Auth.ValidateSession(token)
+		if err != nil {
+			if !errors.Is(err, auth.ErrInvalidSession) {
+				log.Error("Failed to validate session: %v", err)
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+
+		api.authorize(c, user)
+	}
+}
+
+func (api *API) authorize(c *gin.Context, user *database.User) {
+	role := requiredRole(c.Request.Method, c.FullPath())
+	if !auth.HasRole(user, role) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("the %q role is required", role),
+		})
+		return
+	}
+
+	c.Set(userContextKey, user)
+	c.Next()
+}
+
+func (api *API) authenticateToken(c *gin.Context, value string) {
+	token, err := api.Auth.ValidateToken(value)
+	if err != nil {
+		if !errors.Is(err, auth.ErrInvalidToken) {
+			log.Error("Failed to validate API token: %v", err)
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired API token"})
+		return
+	}
+
+	scope := requiredScope(c.Request.Method, c.FullPath())
+	if !auth.Allows(token, scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("API token is missing the %q scope", scope),
+		})
+		return
+	}
+
+	c.Set(tokenContextKey, token)
+	api.authorize(c, &token.User)
+}
+
+// requiredScope returns the scope an API token needs for the route.
+func requiredScope(method, path string) database.TokenScope {
+	switch {
+	case strings.HasPrefix(path, "/api/auth/"), isAdminPath(path):
+		return database.ScopeAdmin
+	case strings.HasPrefix(path, "/api/monitor/:id/push"):
+		return database.ScopePush
+	case method == http.MethodGet || method == http.MethodHead:
+		return database.ScopeRead
+	default:
+		return database.ScopeMonitorWrite
+	}
+}
+
+// requiredRole returns the least role a user needs for the route. Operator
+// routes are checked before read methods as pushes are also accepted over GET.
+func requiredRole(method, path string) database.Role {
+	switch {
+	case isAdminPath(path):
+		return database.RoleAdmin
+	case strings.HasPrefix(path, "/api/auth/"):
+		return database.RoleViewer
+	case isOperatorPath(path):
+		return database.RoleOperator
+	case method == http.MethodGet || method == http.MethodHead:
+		return database.RoleViewer
+	default:
+		return database.RoleEditor
+	}
+}
+
+func isAdminPath(path string) bool {
+	switch path {
+	case "/api/monitors/import", "/api/monitors/sync":
+		return true
+	}
+	return strings.HasPrefix(path, "/api/users") || strings.HasPrefix(path, "/api/teams")
+}
+
+// isOperatorPath reports whether the route only acts on existing monitors
+// without changing their configuration.
+func isOperatorPath(path string) bool {
+	switch path {
+	case "/api/monitor/:id/run",
+		"/api/monitor/:id/pause",
+		"/api/monitor/:id/resume",
+		"/api/incidents/:id/acknowledge":
+		return true
+	}
+	return strings.HasPrefix(path, "/api/monitor/:id/push")
+}
+
+// access returns the monitors visible to the user of the request, admins and
+// installations without authentication see all of them.
+func (api *API) access(c *gin.Context) monitor.Access {
+	user := currentUser(c)
+	if !api.Authentication || user == nil || user.Role == database.RoleAdmin {
+		return monitor.FullAccess
+	}
+	return monitor.Access{TeamIDs: auth.TeamIDs(user)}
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func currentUser(c *gin.Context) *database.User {
+	user, _ := c.Get(userContextKey)
+	u, _ := user.(*database.User)
+	return u
+}
+
+func (api *API) login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	user, err := api.Auth.Authenticate(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			log.Warning("Failed login attempt for user %q from %s", req.Username, c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to authenticate user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to authenticate"})
+		return
+	}
+
+	token, session, err := api.Auth.CreateSession(user)
+	if err != nil {
+		log.Error("Failed to create session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+
+	api.setSessionCookie(c, token, int(api.Auth.SessionTTL.Seconds()))
+	c.JSON(http.StatusOK, gin.H{
+		"user":      user,
+		"expiresAt": session.ExpiresAt,
+	})
+}
+
+func (api *API) logout(c *gin.Context) {
+	if token, err := c.Cookie(sessionCookie); err == nil {
+		if err := api.Auth.DeleteSession(token); err != nil {
+			log.Error("Failed to delete session: %v", err)
+		}
+	}
+
+	api.setSessionCookie(c, "", -1)
+	c.Status(http.StatusOK)
+}
+
+func (api *API) getCurrentUser(c *gin.Context) {
+	c.JSON(http.StatusOK, currentUser(c))
+}
+
+func (api *API) changePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	token, _ := c.Cookie(sessionCookie)
+
+	err := api.Auth.ChangePassword(currentUser(c), req.CurrentPassword, req.NewPassword, token)
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		c.JSON(http.StatusForbidden, gin.H{"error": "current password is incorrect"})
+	case errors.Is(err, auth.ErrWeakPassword):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Error("Failed to change password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+	default:
+		c.Status(http.StatusOK)
+	}
+}
+
+func (api *API) setSessionCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookie, value, maxAge, "/", "", c.Request.TLS != nil, true)
+}
+
+func (api *API) listTokens(c *gin.Context) {
+	tokens, err := api.Auth.ListTokens(currentUser(c))
+	if err != nil {
+		log.Error("Failed to list API tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list API tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (api *API) createToken(c *gin.Context) {
+	var req NewAPIToken
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	value, token, err := api.Auth.CreateToken(currentUser(c), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		log.Warning("Failed to create API token: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The token value is only returned once, only its hash is stored
+	c.JSON(http.StatusOK, gin.H{
+		"token":    value,
+		"apiToken": token,
+	})
+}
+
+func (api *API) revokeToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid token id"})
+		return
+	}
+
+	if err := api.Auth.RevokeToken(currentUser(c), uint(id)); err != nil {
+		if errors.Is(err, auth.ErrTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to revoke API token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke API token"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
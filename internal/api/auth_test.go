@@ -0,0 +1,289 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"honk/internal/auth"
+	"honk/internal/database"
+	"honk/internal/monitor"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestAPI serves the routes with authentication on a fresh database.
+func newTestAPI(t *testing.T) *API {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/honk.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	manager := monitor.NewManager(db)
+	manager.RegisterHandler(database.ConnectionTypePush, monitor.NewPushHandler())
+	t.Cleanup(manager.Stop)
+
+	api := &API{Authentication: true, Manager: manager, Auth: auth.NewService(db)}
+	api.initializeRouter()
+	api.configureCORS()
+	api.setupRoutes()
+	return api
+}
+
+// Routes registered on the public group, they are never authorized
+var publicRoutes = map[string]bool{
+	"POST /api/auth/login":        true,
+	"POST /api/auth/logout":       true,
+	"GET /api/push/:token":        true,
+	"POST /api/push/:token":       true,
+	"GET /api/push/:token/fail":   true,
+	"POST /api/push/:token/fail":  true,
+	"GET /api/push/:token/start":  true,
+	"POST /api/push/:token/start": true,
+}
+
+func TestRequiredRoleAndScope(t *testing.T) {
+	type access struct {
+		role  database.Role
+		scope database.TokenScope
+	}
+
+	var (
+		viewer   = access{database.RoleViewer, database.ScopeRead}
+		operator = access{database.RoleOperator, database.ScopeMonitorWrite}
+		pusher   = access{database.RoleOperator, database.ScopePush}
+		editor   = access{database.RoleEditor, database.ScopeMonitorWrite}
+		admin    = access{database.RoleAdmin, database.ScopeAdmin}
+		account  = access{database.RoleViewer, database.ScopeAdmin}
+	)
+
+	expected := map[string]access{
+		"GET /api/auth/me":            account,
+		"POST /api/auth/password":     account,
+		"GET /api/auth/tokens":        account,
+		"POST /api/auth/tokens":       account,
+		"DELETE /api/auth/tokens/:id": account,
+
+		"GET /api/users":        admin,
+		"POST /api/users":       admin,
+		"PUT /api/users/:id":    admin,
+		"DELETE /api/users/:id": admin,
+		"GET /api/teams":        admin,
+		"POST /api/teams":       admin,
+		"PUT /api/teams/:id":    admin,
+		"DELETE /api/teams/:id": admin,
+
+		"POST /api/monitors/import": admin,
+		"GET /api/monitors/sync":    admin,
+		"GET /api/monitors/export":  viewer,
+
+		"GET /api/info":                  viewer,
+		"GET /api/stats":                 viewer,
+		"GET /api/ws":                    viewer,
+		"GET /api/monitors":              viewer,
+		"GET /api/monitors/dependencies": viewer,
+		"GET /api/monitor/:id":           viewer,
+		"GET /api/monitor/:id/checks":    viewer,
+		"GET /api/monitor/:id/incidents": viewer,
+		"GET /api/monitor/:id/stats":     viewer,
+
+		"POST /api/monitor":            editor,
+		"PUT /api/monitor/:id":         editor,
+		"DELETE /api/monitor/:id":      editor,
+		"POST /api/monitor/:id/run":    operator,
+		"POST /api/monitor/:id/pause":  operator,
+		"POST /api/monitor/:id/resume": operator,
+
+		"POST /api/incidents/:id/acknowledge": operator,
+
+		"GET /api/monitor/:id/push":        pusher,
+		"POST /api/monitor/:id/push":       pusher,
+		"GET /api/monitor/:id/push/fail":   pusher,
+		"POST /api/monitor/:id/push/fail":  pusher,
+		"GET /api/monitor/:id/push/start":  pusher,
+		"POST /api/monitor/:id/push/start": pusher,
+
+		"GET /api/notifications":           viewer,
+		"GET /api/notifications/platforms": viewer,
+		"GET /api/notifications/:id":       viewer,
+		"POST /api/notifications":          editor,
+		"PUT /api/notifications/:id":       editor,
+		"DELETE /api/notifications/:id":    editor,
+		"POST /api/webhook/test":           editor,
+
+		"GET /api/maintenance":        viewer,
+		"GET /api/maintenance/:id":    viewer,
+		"POST /api/maintenance":       editor,
+		"PUT /api/maintenance/:id":    editor,
+		"DELETE /api/maintenance/:id": editor,
+	}
+
+	api := &API{Authentication: true, Dashboard: true, Metrics: true}
+	api.initializeRouter()
+	api.configureCORS()
+	api.setupRoutes()
+
+	seen := map[string]bool{}
+	for _, route := range api.router.Routes() {
+		key := route.Method + " " + route.Path
+		if publicRoutes[key] || route.Path == "/metrics" {
+			continue
+		}
+		seen[key] = true
+
+		want, ok := expected[key]
+		if !ok {
+			t.Errorf("%s is not covered, add the role and scope it needs", key)
+			continue
+		}
+		if got := requiredRole(route.Method, route.Path); got != want.role {
+			t.Errorf("%s: expected role %q, got %q", key, want.role, got)
+		}
+		if got := requiredScope(route.Method, route.Path); got != want.scope {
+			t.Errorf("%s: expected scope %q, got %q", key, want.scope, got)
+		}
+	}
+
+	for key := range expected {
+		if !seen[key] {
+			t.Errorf("%s is expected but not registered", key)
+		}
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	api := newTestAPI(t)
+
+	teamA, err := api.Auth.CreateTeam("a")
+	if err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	teamB, err := api.Auth.CreateTeam("b")
+	if err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	sessions := map[string]string{}
+	users := map[string]*database.User{}
+	for _, u := range []struct {
+		name string
+		role database.Role
+		team *database.Team
+	}{
+		{"viewer", database.RoleViewer, teamA},
+		{"operator", database.RoleOperator, teamA},
+		{"editor", database.RoleEditor, teamA},
+		{"outsider", database.RoleEditor, teamB},
+		{"admin", database.RoleAdmin, nil},
+	} {
+		user, err := api.Auth.CreateUser(u.name, "password123", u.role)
+		if err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		if u.team != nil {
+			if user, err = api.Auth.UpdateUser(user.ID, auth.UserUpdate{TeamIDs: []uint{u.team.ID}}); err != nil {
+				t.Fatalf("failed to add user to team: %v", err)
+			}
+		}
+		if sessions[u.name], _, err = api.Auth.CreateSession(user); err != nil {
+			t.Fatalf("failed to create session: %v", err)
+		}
+		users[u.name] = user
+	}
+
+	tokens := map[string]string{}
+	for _, tk := range []struct {
+		name  string
+		user  string
+		scope database.TokenScope
+	}{
+		{"read", "editor", database.ScopeRead},
+		{"push", "editor", database.ScopePush},
+		{"write", "operator", database.ScopeMonitorWrite},
+		{"viewer-admin", "viewer", database.ScopeAdmin},
+	} {
+		value, _, err := api.Auth.CreateToken(users[tk.user], tk.name, []database.TokenScope{tk.scope}, nil)
+		if err != nil {
+			t.Fatalf("failed to create token: %v", err)
+		}
+		tokens[tk.name] = value
+	}
+
+	mon, err := api.Manager.AddMonitor(&database.Monitor{
+		Name:           "job",
+		ConnectionType: database.ConnectionTypePush,
+		Enabled:        true,
+		Interval:       60,
+		TeamID:         &teamA.ID,
+	})
+	if err != nil {
+		t.Fatalf("failed to add monitor: %v", err)
+	}
+	id := strconv.Itoa(int(mon.ID))
+
+	tests := []struct {
+		name    string
+		session string
+		token   string
+		method  string
+		path    string
+		status  int
+	}{
+		{"no credentials", "", "", http.MethodGet, "/api/monitors", http.StatusUnauthorized},
+		{"unknown token", "", "honk_unknown", http.MethodGet, "/api/monitors", http.StatusUnauthorized},
+		{"unknown session", "unknown", "", http.MethodGet, "/api/monitors", http.StatusUnauthorized},
+
+		{"viewer reads", "viewer", "", http.MethodGet, "/api/monitor/" + id, http.StatusOK},
+		{"viewer cannot pause", "viewer", "", http.MethodPost, "/api/monitor/" + id + "/pause", http.StatusForbidden},
+		{"viewer cannot push over GET", "viewer", "", http.MethodGet, "/api/monitor/" + id + "/push", http.StatusForbidden},
+		{"operator pauses", "operator", "", http.MethodPost, "/api/monitor/" + id + "/pause", http.StatusOK},
+		{"operator resumes", "operator", "", http.MethodPost, "/api/monitor/" + id + "/resume", http.StatusOK},
+		{"operator cannot delete", "operator", "", http.MethodDelete, "/api/monitor/" + id, http.StatusForbidden},
+		{"editor cannot list users", "editor", "", http.MethodGet, "/api/users", http.StatusForbidden},
+		{"admin lists users", "admin", "", http.MethodGet, "/api/users", http.StatusOK},
+		{"other team does not see the monitor", "outsider", "", http.MethodGet, "/api/monitor/" + id, http.StatusNotFound},
+		{"other team cannot pause the monitor", "outsider", "", http.MethodPost, "/api/monitor/" + id + "/pause", http.StatusNotFound},
+
+		{"read token reads", "", "read", http.MethodGet, "/api/monitors", http.StatusOK},
+		{"read token cannot pause", "", "read", http.MethodPost, "/api/monitor/" + id + "/pause", http.StatusForbidden},
+		{"read token cannot manage tokens", "", "read", http.MethodGet, "/api/auth/tokens", http.StatusForbidden},
+		{"push token pushes", "", "push", http.MethodGet, "/api/monitor/" + id + "/push", http.StatusOK},
+		{"push token cannot read", "", "push", http.MethodGet, "/api/monitors", http.StatusForbidden},
+		{"write token runs checks", "", "write", http.MethodPost, "/api/monitor/" + id + "/run", http.StatusOK},
+		{"token scope does not raise the role", "", "viewer-admin", http.MethodPost, "/api/monitor/" + id + "/pause", http.StatusForbidden},
+		{"admin token of a viewer reads", "", "viewer-admin", http.MethodGet, "/api/monitors", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			switch {
+			case tt.token != "":
+				value, ok := tokens[tt.token]
+				if !ok {
+					value = tt.token
+				}
+				req.Header.Set("Authorization", "Bearer "+value)
+			case tt.session != "":
+				value, ok := sessions[tt.session]
+				if !ok {
+					value = tt.session
+				}
+				req.AddCookie(&http.Cookie{Name: sessionCookie, Value: value})
+			}
+
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
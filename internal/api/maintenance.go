@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"honk/internal/monitor"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) registerMaintenanceRoutes() {
+	api.routes.POST("/maintenance", api.createMaintenance)
+
+	api.routes.GET("/maintenance", api.listMaintenance)
+	api.routes.GET("/maintenance/:id", api.getMaintenance)
+
+	api.routes.PUT("/maintenance/:id", api.updateMaintenance)
+
+	api.routes.DELETE("/maintenance/:id", api.deleteMaintenance)
+}
+
+func (api *API) createMaintenance(c *gin.Context) {
+	var req NewMaintenanceWindow
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warning("Invalid maintenance payload: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body",
+		})
+		return
+	}
+
+	window := req.toMaintenanceWindow()
+	if err := api.Manager.AddMaintenance(window, api.access(c)); err != nil {
+		log.Warning("Failed to add maintenance window: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
+
+func (api *API) listMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, api.Manager.ListMaintenance(api.access(c)))
+}
+
+func (api *API) getMaintenance(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance id"})
+		return
+	}
+
+	window := api.Manager.GetMaintenance(uint(id), api.access(c))
+	if window == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("maintenance window with id '%d' not found", id),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
+
+func (api *API) updateMaintenance(c *gin.Context) {
+	var req NewMaintenanceWindow
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warning("Invalid maintenance payload: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body",
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance id"})
+		return
+	}
+
+	window := req.toMaintenanceWindow()
+	window.ID = uint(id)
+
+	if err := api.Manager.UpdateMaintenance(window, api.access(c)); err != nil {
+		log.Warning("Failed to update maintenance window: %v", err)
+		status := http.StatusBadRequest
+		if errors.Is(err, monitor.ErrMaintenanceNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
+
+func (api *API) deleteMaintenance(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance id"})
+		return
+	}
+
+	if err := api.Manager.RemoveMaintenance(uint(id), api.access(c)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, monitor.ErrMaintenanceNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) registerMetricsRoutes() {
+	// Prometheus expects the metrics at the root rather than below /api
+	handlers := []gin.HandlerFunc{api.getMetrics}
+	if api.Authentication {
+		handlers = append([]gin.HandlerFunc{api.authMiddleware()}, handlers...)
+	}
+
+	api.router.GET("/metrics", handlers...)
+}
+
+func (api *API) getMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	if err := api.Manager.WriteMetrics(c.Writer, api.access(c)); err != nil {
+		log.Error("Failed to write metrics: %v", err)
+	}
+}
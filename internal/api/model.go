@@ -1,24 +1,359 @@
 package api
 
-import "honk/internal/database"
+import (
+	"encoding/json"
+	"honk/internal/auth"
+	"honk/internal/database"
+	"honk/internal/monitor"
+	"reflect"
+	"strings"
+	"time"
+)
 
 type NewMonitor struct {
 	Enabled        *bool                   `json:"enabled" binding:"required"`
+	Key            string                  `json:"key" binding:"max=64"`
 	Name           string                  `json:"name" binding:"max=64"`
-	Connection     string                  `json:"connection" binding:"required"`
+	Connection     string                  `json:"connection" binding:"required_unless=ConnectionType push"`
 	ConnectionType database.ConnectionType `json:"connectionType" binding:"required"`
 	HTTPMethod     string                  `json:"httpMethod"`
 	Timeout        int                     `json:"timeout"`
 	Body           string                  `json:"body"`
+	BodyType       database.BodyType       `json:"bodyType"`
+	ContentType    string                  `json:"contentType"`
 	Interval       int                     `json:"interval" binding:"required"`
 	AlwaysSave     *bool                   `json:"alwaysSave" binding:"required"`
-	Notification   database.Notification   `json:"notification"`
+	Notifications  []NotificationLink      `json:"notifications"`
+	Tags           []string                `json:"tags"`
+	ParentIDs      []uint                  `json:"parentIds"`
+	TeamID         *uint                   `json:"teamId"`
 
-	// Headers used for the http monitor
-	HttpMonitorHeaders []database.HttpMonitorHeader `json:"headers"`
+	// Confirmation before the monitor is marked down or up
+	RetriesBeforeDown int `json:"retriesBeforeDown" binding:"min=0"`
+	RetryInterval     int `json:"retryInterval" binding:"min=0"`
+	SuccessesBeforeUp int `json:"successesBeforeUp" binding:"min=0"`
+
+	// Seconds between reminder notifications while down, 0 disables them
+	ReminderInterval int `json:"reminderInterval" binding:"min=0"`
+
+	// Certificate expiry checks for tls and https monitors
+	CertExpiryDays     int  `json:"certExpiryDays"`
+	CertExpiryWarnOnly bool `json:"certExpiryWarnOnly"`
+
+	// DNS record checks
+	DNSRecordType string                `json:"dnsRecordType"`
+	DNSResolver   string                `json:"dnsResolver"`
+	DNSMatch      database.DNSMatchMode `json:"dnsMatch"`
+	DNSExpected   string                `json:"dnsExpected"`
+	DNSMinTTL     int                   `json:"dnsMinTTL"`
+	DNSMaxTTL     int                   `json:"dnsMaxTTL"`
+
+	// Docker container checks
+	ContainerFailOnUnhealthy bool `json:"containerFailOnUnhealthy"`
+	ContainerMaxRestarts     int  `json:"containerMaxRestarts"`
+	ContainerRestartWindow   int  `json:"containerRestartWindow"`
+
+	// ICMP ping checks
+	PingCount         int `json:"pingCount"`
+	PingLossThreshold int `json:"pingLossThreshold"`
+
+	// Grace period added to the interval of push monitors
+	GracePeriod int `json:"gracePeriod"`
+
+	// Headers and response assertions used for the http monitor
+	HttpMonitorHeaders    []database.HttpMonitorHeader    `json:"headers"`
+	HttpMonitorAssertions []database.HttpMonitorAssertion `json:"assertions"`
+
+	// Settings of the single notification replaced by channels, rejected so
+	// older clients do not lose them without notice
+	Notification json.RawMessage `json:"notification"`
+}
+
+const legacyNotificationError = "notification is no longer supported, link notification channels with notifications instead"
+
+func (req NewMonitor) legacyNotification() bool {
+	return len(req.Notification) > 0 && string(req.Notification) != "null"
+}
+
+func (req NewMonitor) toMonitor() *database.Monitor {
+	return &database.Monitor{
+		Enabled:                  *req.Enabled,
+		Key:                      req.Key,
+		Name:                     req.Name,
+		Connection:               req.Connection,
+		ConnectionType:           req.ConnectionType,
+		HTTPMethod:               req.HTTPMethod,
+		Timeout:                  req.Timeout,
+		Body:                     req.Body,
+		BodyType:                 req.BodyType,
+		ContentType:              req.ContentType,
+		Interval:                 req.Interval,
+		AlwaysSave:               *req.AlwaysSave,
+		RetriesBeforeDown:        req.RetriesBeforeDown,
+		RetryInterval:            req.RetryInterval,
+		SuccessesBeforeUp:        req.SuccessesBeforeUp,
+		ReminderInterval:         req.ReminderInterval,
+		Notifications:            toMonitorNotifications(req.Notifications),
+		Tags:                     req.Tags,
+		ParentIDs:                req.ParentIDs,
+		TeamID:                   req.TeamID,
+		CertExpiryDays:           req.CertExpiryDays,
+		CertExpiryWarnOnly:       req.CertExpiryWarnOnly,
+		DNSRecordType:            req.DNSRecordType,
+		DNSResolver:              req.DNSResolver,
+		DNSMatch:                 req.DNSMatch,
+		DNSExpected:              req.DNSExpected,
+		DNSMinTTL:                req.DNSMinTTL,
+		DNSMaxTTL:                req.DNSMaxTTL,
+		ContainerFailOnUnhealthy: req.ContainerFailOnUnhealthy,
+		ContainerMaxRestarts:     req.ContainerMaxRestarts,
+		ContainerRestartWindow:   req.ContainerRestartWindow,
+		PingCount:                req.PingCount,
+		PingLossThreshold:        req.PingLossThreshold,
+		GracePeriod:              req.GracePeriod,
+		HttpMonitorHeaders:       req.HttpMonitorHeaders,
+		HttpMonitorAssertions:    req.HttpMonitorAssertions,
+	}
+}
+
+// monitorRequest returns the request that would recreate the monitor, links
+// are left nil so they are kept.
+func monitorRequest(mon *database.Monitor) NewMonitor {
+	enabled, alwaysSave := mon.Enabled, mon.AlwaysSave
+	return NewMonitor{
+		Enabled:                  &enabled,
+		Key:                      mon.Key,
+		Name:                     mon.Name,
+		Connection:               mon.Connection,
+		ConnectionType:           mon.ConnectionType,
+		HTTPMethod:               mon.HTTPMethod,
+		Timeout:                  mon.Timeout,
+		Body:                     mon.Body,
+		BodyType:                 mon.BodyType,
+		ContentType:              mon.ContentType,
+		Interval:                 mon.Interval,
+		AlwaysSave:               &alwaysSave,
+		Tags:                     mon.Tags,
+		ParentIDs:                mon.ParentIDs,
+		TeamID:                   mon.TeamID,
+		RetriesBeforeDown:        mon.RetriesBeforeDown,
+		RetryInterval:            mon.RetryInterval,
+		SuccessesBeforeUp:        mon.SuccessesBeforeUp,
+		ReminderInterval:         mon.ReminderInterval,
+		CertExpiryDays:           mon.CertExpiryDays,
+		CertExpiryWarnOnly:       mon.CertExpiryWarnOnly,
+		DNSRecordType:            mon.DNSRecordType,
+		DNSResolver:              mon.DNSResolver,
+		DNSMatch:                 mon.DNSMatch,
+		DNSExpected:              mon.DNSExpected,
+		DNSMinTTL:                mon.DNSMinTTL,
+		DNSMaxTTL:                mon.DNSMaxTTL,
+		ContainerFailOnUnhealthy: mon.ContainerFailOnUnhealthy,
+		ContainerMaxRestarts:     mon.ContainerMaxRestarts,
+		ContainerRestartWindow:   mon.ContainerRestartWindow,
+		PingCount:                mon.PingCount,
+		PingLossThreshold:        mon.PingLossThreshold,
+		GracePeriod:              mon.GracePeriod,
+		HttpMonitorHeaders:       mon.HttpMonitorHeaders,
+		HttpMonitorAssertions:    mon.HttpMonitorAssertions,
+	}
+}
+
+// merge applies a JSON body on top of the request, fields missing from the
+// body keep their value. Present fields are cleared first so slices are
+// replaced instead of decoded into the existing elements.
+func (req *NewMonitor) merge(body []byte) error {
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(body, &present); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(req).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if _, ok := present[name]; ok {
+			v.Field(i).SetZero()
+		}
+	}
+
+	return json.Unmarshal(body, req)
+}
+
+// NotificationLink selects the events of a monitor sent to a channel, the
+// default events are used when none are given.
+type NotificationLink struct {
+	NotificationID uint                         `json:"notificationId" binding:"required"`
+	Events         []database.NotificationEvent `json:"events"`
+}
+
+// toMonitorNotifications keeps nil links nil, so new monitors get the default
+// channels and updates keep their links.
+func toMonitorNotifications(links []NotificationLink) []database.MonitorNotification {
+	if links == nil {
+		return nil
+	}
+
+	result := make([]database.MonitorNotification, 0, len(links))
+	for _, link := range links {
+		result = append(result, database.MonitorNotification{
+			NotificationID: link.NotificationID,
+			Events:         link.Events,
+		})
+	}
+	return result
+}
+
+type NewNotificationChannel struct {
+	Name     string            `json:"name" binding:"required,max=64"`
+	Enabled  *bool             `json:"enabled" binding:"required"`
+	Default  bool              `json:"default"`
+	Type     string            `json:"type"`
+	Webhook  string            `json:"webhook"`
+	Email    string            `json:"email"`
+	Template database.Template `json:"template"`
+
+	// Channels of other users default to their team, or the current team of
+	// the channel when it is updated
+	TeamID *uint `json:"teamId"`
+
+	WebhookOptions NewWebhookOptions `json:"webhookOptions"`
+}
+
+// NewWebhookOptions accepts the secret that is never returned. An empty secret
+// keeps the stored one unless ClearSecret is set.
+type NewWebhookOptions struct {
+	Method      string            `json:"method"`
+	Headers     map[string]string `json:"headers"`
+	Secret      string            `json:"secret"`
+	Body        string            `json:"body"`
+	ClearSecret bool              `json:"clearSecret"`
+}
+
+func (req NewWebhookOptions) toWebhookOptions() database.WebhookOptions {
+	return database.WebhookOptions{
+		Method:  req.Method,
+		Headers: req.Headers,
+		Secret:  req.Secret,
+		Body:    req.Body,
+	}
+}
+
+// TestNotification is a channel to send a test message with, a saved channel
+// is tested with its stored secret when none is given.
+type TestNotification struct {
+	database.Notification
+	WebhookOptions NewWebhookOptions `json:"webhookOptions"`
+}
+
+func (req NewNotificationChannel) toNotification() *database.Notification {
+	return &database.Notification{
+		Name:           req.Name,
+		Enabled:        *req.Enabled,
+		Default:        req.Default,
+		Type:           req.Type,
+		Webhook:        req.Webhook,
+		Email:          req.Email,
+		Template:       req.Template,
+		TeamID:         req.TeamID,
+		WebhookOptions: req.WebhookOptions.toWebhookOptions(),
+	}
+}
+
+type NewMaintenanceWindow struct {
+	Name        string                   `json:"name" binding:"required,max=64"`
+	Description string                   `json:"description"`
+	Mode        database.MaintenanceMode `json:"mode"`
+	StartsAt    time.Time                `json:"startsAt" binding:"required"`
+	EndsAt      time.Time                `json:"endsAt" binding:"required"`
+	Recurrence  database.Recurrence      `json:"recurrence"`
+	RepeatUntil *time.Time               `json:"repeatUntil"`
+	Timezone    string                   `json:"timezone"`
+	MonitorIDs  []uint                   `json:"monitorIds"`
+	Tags        []string                 `json:"tags"`
+
+	// Only used for admins, windows of other users belong to their teams
+	TeamIDs []uint `json:"teamIds"`
+}
+
+func (req NewMaintenanceWindow) toMaintenanceWindow() *database.MaintenanceWindow {
+	return &database.MaintenanceWindow{
+		Name:        req.Name,
+		Description: req.Description,
+		Mode:        req.Mode,
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+		Recurrence:  req.Recurrence,
+		RepeatUntil: req.RepeatUntil,
+		Timezone:    req.Timezone,
+		MonitorIDs:  req.MonitorIDs,
+		Tags:        req.Tags,
+		TeamIDs:     req.TeamIDs,
+	}
+}
+
+type CheckQuery struct {
+	Cursor        uint      `form:"cursor"`
+	Limit         int       `form:"limit" binding:"min=0"`
+	From          time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To            time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Status        string    `form:"status" binding:"omitempty,oneof=success failure"`
+	IncludeResult bool      `form:"result"`
+}
+
+func (req CheckQuery) toCheckQuery() monitor.CheckQuery {
+	query := monitor.CheckQuery{
+		Before:        req.Cursor,
+		Limit:         req.Limit,
+		From:          req.From,
+		To:            req.To,
+		IncludeResult: req.IncludeResult,
+	}
+
+	if req.Status != "" {
+		success := req.Status == "success"
+		query.Success = &success
+	}
+
+	return query
+}
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+type NewAPIToken struct {
+	Name      string                `json:"name" binding:"required,max=64"`
+	Scopes    []database.TokenScope `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time            `json:"expiresAt"`
+}
+
+type NewUser struct {
+	Username string        `json:"username" binding:"required,max=64"`
+	Password string        `json:"password" binding:"required"`
+	Role     database.Role `json:"role" binding:"required"`
+	TeamIDs  []uint        `json:"teamIds"`
+}
+
+type UpdateUser struct {
+	Password *string        `json:"password"`
+	Role     *database.Role `json:"role"`
+	TeamIDs  []uint         `json:"teamIds"`
+}
+
+func (req UpdateUser) toUserUpdate() auth.UserUpdate {
+	return auth.UserUpdate{
+		Password: req.Password,
+		Role:     req.Role,
+		TeamIDs:  req.TeamIDs,
+	}
 }
 
-type UpdateMonitor struct {
-	ID int `json:"id"`
-	NewMonitor
+type NewTeam struct {
+	Name string `json:"name" binding:"required,max=64"`
 }
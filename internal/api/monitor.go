@@ -1,21 +1,31 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"honk/internal/database"
+	"honk/internal/monitor"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 func (api *API) registerMonitorRoutes() {
 	api.routes.POST("/monitor", api.createMonitor)
 	api.routes.POST("/monitor/:id/run", api.runMonitor)
+	api.routes.POST("/monitor/:id/pause", api.pauseMonitor)
+	api.routes.POST("/monitor/:id/resume", api.resumeMonitor)
+	api.routes.POST("/incidents/:id/acknowledge", api.acknowledgeIncident)
 
 	api.routes.GET("/monitors", api.listMonitors)
 	api.routes.GET("/monitor/:id", api.getMonitor)
+	api.routes.GET("/monitor/:id/checks", api.listChecks)
+	api.routes.GET("/monitor/:id/incidents", api.listIncidents)
+	api.routes.GET("/monitors/dependencies", api.getDependencies)
 
 	api.routes.PUT("/monitor/:id", api.updateMonitor)
 
@@ -31,23 +41,29 @@ func (api *API) createMonitor(c *gin.Context) {
 		})
 		return
 	}
+	if req.legacyNotification() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": legacyNotificationError})
+		return
+	}
 
-	monitor := &database.Monitor{
-		Enabled:            *req.Enabled,
-		Name:               req.Name,
-		ConnectionType:     req.ConnectionType,
-		HTTPMethod:         req.HTTPMethod,
-		Connection:         req.Connection,
-		Interval:           req.Interval,
-		AlwaysSave:         *req.AlwaysSave,
-		Notification:       req.Notification,
-		HttpMonitorHeaders: req.HttpMonitorHeaders,
+	access := api.access(c)
+	monitor := req.toMonitor()
+	if monitor.TeamID == nil {
+		monitor.TeamID = access.DefaultTeam()
+	}
+	if !access.CanAssign(monitor.TeamID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": assignError(monitor.TeamID)})
+		return
 	}
 
 	newMonitor, err := api.Manager.AddMonitor(monitor)
 	if err != nil {
 		log.Warning("Failed to add monitor: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if isValidationError(err) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -57,13 +73,12 @@ func (api *API) createMonitor(c *gin.Context) {
 }
 
 func (api *API) runMonitor(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid monitor id"})
+	mon, ok := api.visibleMonitor(c)
+	if !ok {
 		return
 	}
 
-	newMonitor, err := api.Manager.RunMonitor(id)
+	newMonitor, err := api.Manager.RunMonitor(int(mon.ID))
 	if err != nil {
 		log.Warning("Failed to run monitor: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -75,57 +90,166 @@ func (api *API) runMonitor(c *gin.Context) {
 	c.JSON(http.StatusOK, newMonitor)
 }
 
+func (api *API) pauseMonitor(c *gin.Context) {
+	api.setMonitorEnabled(c, false)
+}
+
+func (api *API) resumeMonitor(c *gin.Context) {
+	api.setMonitorEnabled(c, true)
+}
+
+func (api *API) setMonitorEnabled(c *gin.Context, enabled bool) {
+	mon, ok := api.modifiableMonitor(c)
+	if !ok {
+		return
+	}
+
+	if err := api.Manager.SetMonitorEnabled(int(mon.ID), enabled); err != nil {
+		log.Warning("Failed to pause or resume monitor: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
 func (api *API) getMonitor(c *gin.Context) {
+	mon, ok := api.visibleMonitor(c)
+	if !ok {
+		return
+	}
+
+	// The check history is only embedded on request, use /checks to page
+	// through all of it
+	if value := c.Query("checks"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checks limit"})
+			return
+		}
+
+		page, err := api.Manager.ListChecks(mon.ID, monitor.CheckQuery{Limit: limit, IncludeResult: true})
+		if err != nil {
+			log.Error("Failed to load checks: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Pages are newest first, the embedded history stays in the
+		// chronological order the charts expect
+		slices.Reverse(page.Checks)
+		mon.Checks = page.Checks
+	}
+
+	c.JSON(http.StatusOK, mon)
+}
+
+func (api *API) listChecks(c *gin.Context) {
+	mon, ok := api.visibleMonitor(c)
+	if !ok {
+		return
+	}
+
+	var req CheckQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	page, err := api.Manager.ListChecks(mon.ID, req.toCheckQuery())
+	if err != nil {
+		log.Error("Failed to load checks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (api *API) listIncidents(c *gin.Context) {
+	mon, ok := api.visibleMonitor(c)
+	if !ok {
+		return
+	}
+
+	incidents, err := api.Manager.ListIncidents(mon.ID)
+	if err != nil {
+		log.Error("Failed to load incidents: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, incidents)
+}
+
+func (api *API) acknowledgeIncident(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid monitor id"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid incident id"})
 		return
 	}
 
-	monitor := api.Manager.GetMonitor(id)
-	if monitor == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": fmt.Sprintf("monitor with id '%d' not found", id),
-		})
+	var userID uint
+	if user := currentUser(c); user != nil {
+		userID = user.ID
+	}
+
+	incident, err := api.Manager.AcknowledgeIncident(uint(id), userID, api.access(c))
+	if err != nil {
+		if errors.Is(err, monitor.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to acknowledge incident: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, monitor)
+	c.JSON(http.StatusOK, incident)
 }
 
+// updateMonitor changes the fields present in the request, others keep their
+// current value.
 func (api *API) updateMonitor(c *gin.Context) {
-	var req UpdateMonitor
-	if err := c.ShouldBindJSON(&req); err != nil {
+	current, ok := api.modifiableMonitor(c)
+	if !ok {
+		return
+	}
+
+	req := monitorRequest(current)
+	body, err := c.GetRawData()
+	if err == nil {
+		err = req.merge(body)
+	}
+	if err == nil {
+		err = binding.Validator.ValidateStruct(&req)
+	}
+	if err != nil {
 		log.Warning("Invalid monitor payload: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "invalid request body",
 		})
 		return
 	}
+	if req.legacyNotification() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": legacyNotificationError})
+		return
+	}
 
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid monitor id"})
+	monitor := req.toMonitor()
+	monitor.ID = current.ID
+	if !sameTeam(monitor.TeamID, current.TeamID) && !api.access(c).CanAssign(monitor.TeamID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": assignError(monitor.TeamID)})
 		return
 	}
 
-	err = api.Manager.UpdateMonitor(&database.Monitor{
-		ID:                 uint(id),
-		Enabled:            *req.Enabled,
-		Name:               req.Name,
-		Connection:         req.Connection,
-		ConnectionType:     req.ConnectionType,
-		HTTPMethod:         req.HTTPMethod,
-		Timeout:            req.Timeout,
-		Body:               req.Body,
-		Interval:           req.Interval,
-		AlwaysSave:         *req.AlwaysSave,
-		Notification:       req.Notification,
-		HttpMonitorHeaders: req.HttpMonitorHeaders,
-	})
+	err = api.Manager.UpdateMonitor(monitor)
 	if err != nil {
 		log.Warning("Failed to update monitor: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if isValidationError(err) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -135,17 +259,20 @@ func (api *API) updateMonitor(c *gin.Context) {
 }
 
 func (api *API) listMonitors(c *gin.Context) {
-	c.JSON(http.StatusOK, api.Manager.ListMonitors())
+	c.JSON(http.StatusOK, api.Manager.ListMonitors(api.access(c)))
+}
+
+func (api *API) getDependencies(c *gin.Context) {
+	c.JSON(http.StatusOK, api.Manager.DependencyGraph(api.access(c)))
 }
 
 func (api *API) deleteMonitor(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid monitor id"})
+	mon, ok := api.modifiableMonitor(c)
+	if !ok {
 		return
 	}
 
-	err = api.Manager.RemoveMonitor(id)
+	err := api.Manager.RemoveMonitor(int(mon.ID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -154,3 +281,56 @@ func (api *API) deleteMonitor(c *gin.Context) {
 	}
 	c.Status(http.StatusOK)
 }
+
+// visibleMonitor loads the monitor of the request, monitors of other teams are
+// reported as not found.
+func (api *API) visibleMonitor(c *gin.Context) (*database.Monitor, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid monitor id"})
+		return nil, false
+	}
+
+	mon := api.Manager.GetMonitor(id, api.access(c))
+	if mon == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("monitor with id '%d' not found", id),
+		})
+		return nil, false
+	}
+
+	return mon, true
+}
+
+// modifiableMonitor is visibleMonitor for requests changing the monitor, shared
+// monitors can only be changed by admins.
+func (api *API) modifiableMonitor(c *gin.Context) (*database.Monitor, bool) {
+	mon, ok := api.visibleMonitor(c)
+	if !ok {
+		return nil, false
+	}
+
+	if !api.access(c).CanModify(mon) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can change monitors shared with every team"})
+		return nil, false
+	}
+	return mon, true
+}
+
+func isValidationError(err error) bool {
+	return errors.Is(err, monitor.ErrInvalidDependency) || errors.Is(err, monitor.ErrInvalidMonitor)
+}
+
+func sameTeam(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func assignError(teamID *uint) string {
+	if teamID == nil {
+		return "only admins can share monitors with every team"
+	}
+	return "not a member of the team"
+}
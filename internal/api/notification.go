@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"honk/internal/database"
+	"honk/internal/monitor"
+	"honk/internal/notification"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) registerNotificationRoutes() {
+	api.routes.POST("/notifications", api.createChannel)
+
+	api.routes.GET("/notifications", api.listChannels)
+	api.routes.GET("/notifications/platforms", api.listPlatforms)
+	api.routes.GET("/notifications/:id", api.getChannel)
+
+	api.routes.PUT("/notifications/:id", api.updateChannel)
+
+	api.routes.DELETE("/notifications/:id", api.deleteChannel)
+}
+
+func (api *API) createChannel(c *gin.Context) {
+	var req NewNotificationChannel
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warning("Invalid notification channel payload: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	access := api.access(c)
+	channel := req.toNotification()
+	if channel.TeamID == nil {
+		channel.TeamID = access.DefaultTeam()
+	}
+	if !access.CanAssign(channel.TeamID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": channelAssignError(channel.TeamID)})
+		return
+	}
+
+	if err := api.Manager.CreateChannel(channel); err != nil {
+		respondChannelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+func (api *API) listChannels(c *gin.Context) {
+	channels, err := api.Manager.ListChannels(api.access(c))
+	if err != nil {
+		log.Error("Failed to list notification channels: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, channels)
+}
+
+// listPlatforms returns the supported platforms, the platform matching the
+// url query parameter is returned as detected for the UI to preselect.
+func (api *API) listPlatforms(c *gin.Context) {
+	platforms := append(notification.WebhookPlatforms(), notification.Email)
+
+	c.JSON(http.StatusOK, gin.H{
+		"platforms": platforms,
+		"detected":  notification.DetectPlatform(c.Query("url")),
+	})
+}
+
+func (api *API) getChannel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification channel id"})
+		return
+	}
+
+	channel, err := api.Manager.GetChannel(uint(id), api.access(c))
+	if err != nil {
+		respondChannelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+func (api *API) updateChannel(c *gin.Context) {
+	var req NewNotificationChannel
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warning("Invalid notification channel payload: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification channel id"})
+		return
+	}
+
+	access := api.access(c)
+	current, ok := api.modifiableChannel(c, uint(id))
+	if !ok {
+		return
+	}
+
+	channel := req.toNotification()
+	channel.ID = current.ID
+	if channel.TeamID == nil && !access.All {
+		channel.TeamID = current.TeamID
+	}
+	if !sameTeam(channel.TeamID, current.TeamID) && !access.CanAssign(channel.TeamID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": channelAssignError(channel.TeamID)})
+		return
+	}
+	keepCredentials(channel, current, req.WebhookOptions.ClearSecret)
+
+	if err := api.Manager.UpdateChannel(channel); err != nil {
+		respondChannelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+func (api *API) deleteChannel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification channel id"})
+		return
+	}
+
+	if _, ok := api.modifiableChannel(c, uint(id)); !ok {
+		return
+	}
+
+	if err := api.Manager.DeleteChannel(uint(id)); err != nil {
+		respondChannelError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// modifiableChannel loads a channel the caller may change, channels of other
+// teams are reported as not found and shared channels are managed by admins.
+func (api *API) modifiableChannel(c *gin.Context, id uint) (*database.Notification, bool) {
+	access := api.access(c)
+	channel, err := api.Manager.GetChannel(id, access)
+	if err != nil {
+		respondChannelError(c, err)
+		return nil, false
+	}
+
+	if !access.CanModifyChannel(channel) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can change channels shared with every team"})
+		return nil, false
+	}
+	return channel, true
+}
+
+func channelAssignError(teamID *uint) string {
+	if teamID == nil {
+		return "only admins can share channels with every team"
+	}
+	return "not a member of the team"
+}
+
+// keepCredentials fills the credentials left empty in a request from the stored
+// channel, responses never include them. The secret is only removed when
+// clearSecret is set. A new URL requires them to be sent again, so stored
+// credentials never reach another host.
+func keepCredentials(channel, current *database.Notification, clearSecret bool) {
+	if channel.Webhook != "" && channel.Webhook != current.Webhook {
+		return
+	}
+	channel.Webhook = current.Webhook
+
+	options := &channel.WebhookOptions
+	if options.Secret == "" && !clearSecret {
+		options.Secret = current.WebhookOptions.Secret
+	}
+	for key, value := range options.Headers {
+		if stored, ok := current.WebhookOptions.Headers[key]; ok && value == "" {
+			options.Headers[key] = stored
+		}
+	}
+}
+
+func respondChannelError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, monitor.ErrChannelNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, monitor.ErrInvalidChannel):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Error("Failed to save notification channel: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"maps"
+	"strings"
+	"testing"
+
+	"honk/internal/database"
+)
+
+func storedChannel() *database.Notification {
+	return &database.Notification{
+		ID:      1,
+		Name:    "ops",
+		Type:    "webhook",
+		Webhook: "https://hooks.example.com/services/T000/B000/XXXX",
+		WebhookOptions: database.WebhookOptions{
+			Method:  "POST",
+			Headers: map[string]string{"Authorization": "Bearer token", "X-Team": "ops"},
+			Secret:  "signing-secret",
+		},
+	}
+}
+
+func TestChannelResponseRedactsCredentials(t *testing.T) {
+	body, err := json.Marshal(storedChannel())
+	if err != nil {
+		t.Fatalf("failed to marshal channel: %v", err)
+	}
+
+	for _, secret := range []string{"XXXX", "Bearer token", "signing-secret"} {
+		if strings.Contains(string(body), secret) {
+			t.Errorf("response contains %q: %s", secret, body)
+		}
+	}
+
+	var response struct {
+		Webhook        string `json:"webhook"`
+		WebhookHost    string `json:"webhookHost"`
+		WebhookOptions struct {
+			Headers   map[string]string `json:"headers"`
+			HasSecret bool              `json:"hasSecret"`
+		} `json:"webhookOptions"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if response.Webhook != "" || response.WebhookHost != "hooks.example.com" {
+		t.Errorf("expected only the host of the URL, got %q and %q", response.Webhook, response.WebhookHost)
+	}
+	if want := map[string]string{"Authorization": "", "X-Team": ""}; !maps.Equal(response.WebhookOptions.Headers, want) {
+		t.Errorf("expected header names without values, got %v", response.WebhookOptions.Headers)
+	}
+	if !response.WebhookOptions.HasSecret {
+		t.Error("expected hasSecret to be set")
+	}
+}
+
+func TestKeepCredentials(t *testing.T) {
+	current := storedChannel()
+
+	channel := &database.Notification{
+		Type: "webhook",
+		WebhookOptions: database.WebhookOptions{
+			Headers: map[string]string{"Authorization": "", "X-Team": "dev", "X-New": ""},
+		},
+	}
+	keepCredentials(channel, current, false)
+
+	if channel.Webhook != current.Webhook {
+		t.Errorf("expected the stored URL, got %q", channel.Webhook)
+	}
+	if channel.WebhookOptions.Secret != "signing-secret" {
+		t.Errorf("expected the stored secret, got %q", channel.WebhookOptions.Secret)
+	}
+	want := map[string]string{"Authorization": "Bearer token", "X-Team": "dev", "X-New": ""}
+	if !maps.Equal(channel.WebhookOptions.Headers, want) {
+		t.Errorf("expected %v, got %v", want, channel.WebhookOptions.Headers)
+	}
+
+	cleared := &database.Notification{Type: "webhook"}
+	keepCredentials(cleared, current, true)
+	if cleared.WebhookOptions.Secret != "" {
+		t.Error("expected the secret to be cleared")
+	}
+
+	moved := &database.Notification{
+		Type:    "webhook",
+		Webhook: "https://attacker.example.net/collect",
+		WebhookOptions: database.WebhookOptions{
+			Headers: map[string]string{"Authorization": ""},
+		},
+	}
+	keepCredentials(moved, current, false)
+	if moved.WebhookOptions.Secret != "" || moved.WebhookOptions.Headers["Authorization"] != "" {
+		t.Errorf("stored credentials were kept for a new URL: %+v", moved.WebhookOptions)
+	}
+	if moved.Webhook != "https://attacker.example.net/collect" {
+		t.Errorf("expected the new URL, got %q", moved.Webhook)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"honk/internal/database"
+	"honk/internal/monitor"
+
+	"github.com/gin-gonic/gin"
+)
+
+var successStatuses = []string{"", "0", "up", "ok", "success"}
+
+func (api *API) registerPushRoutes() {
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		api.public.Handle(method, "/push/:token", api.handlePush(monitor.PushSuccess))
+		api.public.Handle(method, "/push/:token/fail", api.handlePush(monitor.PushFail))
+		api.public.Handle(method, "/push/:token/start", api.handlePush(monitor.PushStart))
+
+		// Same as above for jobs using an API token instead of the secret URL
+		api.routes.Handle(method, "/monitor/:id/push", api.handlePush(monitor.PushSuccess))
+		api.routes.Handle(method, "/monitor/:id/push/fail", api.handlePush(monitor.PushFail))
+		api.routes.Handle(method, "/monitor/:id/push/start", api.handlePush(monitor.PushStart))
+	}
+}
+
+// handlePush records a ping from a job. The optional status, duration (ms) and
+// msg parameters can be sent as query parameters or form values.
+func (api *API) handlePush(kind monitor.PushKind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ping := monitor.PushPing{
+			Kind:    kind,
+			Message: pushParam(c, "msg"),
+		}
+
+		status := strings.ToLower(pushParam(c, "status"))
+		if kind == monitor.PushSuccess && !slices.Contains(successStatuses, status) {
+			ping.Kind = monitor.PushFail
+		}
+
+		if duration := pushParam(c, "duration"); duration != "" {
+			ms, err := strconv.ParseInt(duration, 10, 64)
+			if err != nil || ms < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid duration"})
+				return
+			}
+			ping.DurationMs = &ms
+		}
+
+		token := c.Param("token")
+		if token == "" {
+			id, err := strconv.Atoi(c.Param("id"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid monitor id"})
+				return
+			}
+
+			access := api.access(c)
+			mon := api.Manager.GetMonitor(id, access)
+			if mon == nil || mon.ConnectionType != database.ConnectionTypePush {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("push monitor with id '%d' not found", id)})
+				return
+			}
+			// Jobs of shared monitors push over the secret URL
+			if !access.CanModify(mon) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "only admins can push to monitors shared with every team"})
+				return
+			}
+			token = mon.PushToken
+		}
+
+		if err := api.Manager.RecordPush(token, ping); err != nil {
+			if errors.Is(err, monitor.ErrUnknownPushToken) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+
+			log.Warning("Failed to record push: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
+
+func pushParam(c *gin.Context, key string) string {
+	if value, ok := c.GetQuery(key); ok {
+		return value
+	}
+	return c.PostForm(key)
+}
@@ -4,6 +4,7 @@ import (
 	"embed"
 	"fmt"
 	"honk/internal"
+	"honk/internal/auth"
 	"honk/internal/monitor"
 	"io/fs"
 	"mime"
@@ -16,25 +17,34 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
 )
 
 var log = internal.GetLogger()
 
 const (
 	maxRetries = 10
+
+	// Origin of the development server of the dashboard
+	devDashboardOrigin = "http://localhost:8081"
 )
 
 type API struct {
-	WSCommunication *websocket.Conn
-	router          *gin.Engine
-	routes          *gin.RouterGroup
+	router *gin.Engine
+	routes *gin.RouterGroup
+	public *gin.RouterGroup
 
 	Authentication bool
 	Dashboard      bool
-	Port           int
+	Metrics        bool
+	Address        string
+
+	// Certificate and key for serving over HTTPS, plain HTTP is used when
+	// they are empty
+	CertFile string
+	KeyFile  string
 
 	Manager *monitor.Manager
+	Auth    *auth.Service
 
 	version, commit, date string
 }
@@ -59,12 +69,18 @@ func (api *API) initializeRouter() {
 
 	api.router.Use(gzip.Gzip(gzip.DefaultCompression))
 	api.routes = api.router.Group("/api")
+
+	// Routes that stay reachable without logging in, e.g. for jobs pinging
+	// push monitors
+	api.public = api.router.Group("/api")
 }
 
 func (api *API) configureCORS() {
 	var (
 		corsConfig = cors.Config{
-			AllowOrigins:     []string{},
+			AllowOriginWithContextFunc: func(c *gin.Context, _ string) bool {
+				return api.checkOrigin(c.Request)
+			},
 			AllowMethods:     []string{"POST", "GET", "PUT", "PATCH", "DELETE", "OPTIONS"},
 			AllowHeaders:     []string{"Content-Type", "Authorization", "Cookie"},
 			ExposeHeaders:    []string{"Set-Cookie"},
@@ -73,12 +89,10 @@ func (api *API) configureCORS() {
 		}
 	)
 
-	if api.Dashboard {
-		corsConfig.AllowOrigins = append(corsConfig.AllowOrigins, "*")
-	} else {
+	if !api.Dashboard {
 		log.Warning("Dashboard UI is disabled")
-		corsConfig.AllowOrigins = append(corsConfig.AllowOrigins, "http://localhost:8081")
 		api.routes.Use(cors.New(corsConfig))
+		api.public.Use(cors.New(corsConfig))
 	}
 
 	api.router.Use(cors.New(corsConfig))
@@ -88,13 +102,21 @@ func (api *API) configureCORS() {
 func (api *API) setupRoutes() {
 	api.registerStatisticRoutes()
 	api.registerMonitorRoutes()
+	api.registerSyncRoutes()
 	api.registerWebhookRoutes()
+	api.registerNotificationRoutes()
+	api.registerPushRoutes()
+	api.registerMaintenanceRoutes()
+	if api.Metrics {
+		api.registerMetricsRoutes()
+	}
+	api.registerWebsocketRoutes()
 }
 
 func (api *API) setupAuthAndMiddleware() {
 	if api.Authentication {
-		//api.setupAuth()
-		//api.routes.Use(api.authMiddleware())
+		api.routes.Use(api.authMiddleware())
+		api.setupAuth()
 	} else {
 		log.Warning("Dashboard authentication is disabled.")
 	}
@@ -102,13 +124,12 @@ func (api *API) setupAuthAndMiddleware() {
 
 func (api *API) startServer(errorChannel chan struct{}) {
 	var (
-		addr     = fmt.Sprintf(":%d", api.Port)
 		listener net.Listener
 		err      error
 	)
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		listener, err = net.Listen("tcp", addr)
+		listener, err = net.Listen("tcp", api.Address)
 		if err == nil {
 			break
 		}
@@ -126,18 +147,43 @@ func (api *API) startServer(errorChannel chan struct{}) {
 		return
 	}
 
+	scheme := "http"
+	if api.tlsEnabled() {
+		scheme = "https"
+	}
+
 	if serverIP, err := GetServerIP(); err == nil {
-		log.Info("Web interface available at http://%s:%d", serverIP, api.Port)
+		log.Info("Web interface available at %s://%s:%s", scheme, serverIP, api.port())
+	} else {
+		log.Info("Web server started on %s", listener.Addr())
+	}
+
+	server := &http.Server{Handler: api.router}
+	if api.tlsEnabled() {
+		err = server.ServeTLS(listener, api.CertFile, api.KeyFile)
 	} else {
-		log.Info("Web server started on port :%d", api.Port)
+		err = server.Serve(listener)
 	}
 
-	if err := api.router.RunListener(listener); err != nil {
+	if err != nil {
 		log.Error("Server error: %v", err)
 		errorChannel <- struct{}{}
 	}
 }
 
+func (api *API) tlsEnabled() bool {
+	return api.CertFile != "" && api.KeyFile != ""
+}
+
+// port returns the port of the listen address for display and the dashboard.
+func (api *API) port() string {
+	_, port, err := net.SplitHostPort(api.Address)
+	if err != nil {
+		return api.Address
+	}
+	return port
+}
+
 func (api *API) serveEmbeddedContent(content embed.FS) {
 	ipAddress, err := GetServerIP()
 	if err != nil {
@@ -199,7 +245,7 @@ func (api *API) serveIndexHTML(content embed.FS, ipAddress string) {
 		return
 	}
 
-	indexWithConfig := injectServerConfig(string(indexContent), ipAddress, api.Port)
+	indexWithConfig := injectServerConfig(string(indexContent), ipAddress, api.port())
 	handleIndexHTML := func(c *gin.Context) {
 		c.Header("Content-Type", "text/html")
 		c.Data(http.StatusOK, "text/html", []byte(indexWithConfig))
@@ -209,11 +255,11 @@ func (api *API) serveIndexHTML(content embed.FS, ipAddress string) {
 	api.router.NoRoute(handleIndexHTML)
 }
 
-func injectServerConfig(htmlContent, serverIP string, port int) string {
+func injectServerConfig(htmlContent, serverIP, port string) string {
 	serverConfigScript := fmt.Sprintf(`<script>
 	window.SERVER_CONFIG = {
 		ip: "%s",
-		port: "%d"
+		port: "%s"
 	};
 	</script>`, serverIP, port)
 
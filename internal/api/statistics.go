@@ -1,13 +1,31 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"time"
+
+	"honk/internal/monitor"
 
 	"github.com/gin-gonic/gin"
 )
 
+var statsPeriods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+type statsRange struct {
+	from, to time.Time
+}
+
 func (api *API) registerStatisticRoutes() {
-	api.routes.GET("/info", api.getInfo)
+	api.public.GET("/info", api.getInfo)
+
+	api.routes.GET("/stats", api.getGlobalStats)
+	api.routes.GET("/monitor/:id/stats", api.getMonitorStats)
 }
 
 func (api *API) getInfo(c *gin.Context) {
@@ -17,3 +35,90 @@ func (api *API) getInfo(c *gin.Context) {
 		"date":    api.date,
 	})
 }
+
+func (api *API) getGlobalStats(c *gin.Context) {
+	access := api.access(c)
+	api.respondStats(c, func(from, to time.Time) (*monitor.Stats, error) {
+		return api.Manager.GlobalStats(from, to, access)
+	})
+}
+
+func (api *API) getMonitorStats(c *gin.Context) {
+	mon, ok := api.visibleMonitor(c)
+	if !ok {
+		return
+	}
+
+	api.respondStats(c, func(from, to time.Time) (*monitor.Stats, error) {
+		return api.Manager.MonitorStats(mon.ID, from, to)
+	})
+}
+
+// respondStats answers with the stats of every default period, or of a single
+// range when "range" or "from"/"to" are given.
+func (api *API) respondStats(c *gin.Context, compute func(from, to time.Time) (*monitor.Stats, error)) {
+	ranges, err := parseStatsRanges(c, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make(map[string]*monitor.Stats, len(ranges))
+	for name, r := range ranges {
+		stats, err := compute(r.from, r.to)
+		if err != nil {
+			log.Error("Failed to compute stats: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		result[name] = stats
+	}
+
+	if len(result) == 1 {
+		for _, stats := range result {
+			c.JSON(http.StatusOK, stats)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func parseStatsRanges(c *gin.Context, now time.Time) (map[string]statsRange, error) {
+	if period := c.Query("range"); period != "" {
+		duration, ok := statsPeriods[period]
+		if !ok {
+			return nil, fmt.Errorf("unknown range %q, expected 24h, 7d, 30d or 90d", period)
+		}
+		return map[string]statsRange{period: {from: now.Add(-duration), to: now}}, nil
+	}
+
+	if c.Query("from") != "" || c.Query("to") != "" {
+		r := statsRange{to: now}
+
+		from, err := time.Parse(time.RFC3339, c.Query("from"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid from time, expected RFC3339")
+		}
+		r.from = from
+
+		if value := c.Query("to"); value != "" {
+			to, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid to time, expected RFC3339")
+			}
+			r.to = to
+		}
+
+		if !r.to.After(r.from) {
+			return nil, fmt.Errorf("from must be before to")
+		}
+		return map[string]statsRange{"custom": r}, nil
+	}
+
+	ranges := make(map[string]statsRange, len(statsPeriods))
+	for name, duration := range statsPeriods {
+		ranges[name] = statsRange{from: now.Add(-duration), to: now}
+	}
+	return ranges, nil
+}
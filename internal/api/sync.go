@@ -0,0 +1,133 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"honk/internal/monitor"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) registerSyncRoutes() {
+	api.routes.GET("/monitors/export", api.exportMonitors)
+	api.routes.POST("/monitors/import", api.importMonitors)
+	api.routes.GET("/monitors/sync", api.planMonitorsFile)
+}
+
+func (api *API) exportMonitors(c *gin.Context) {
+	format, ok := specFormat(c)
+	if !ok {
+		return
+	}
+
+	doc, err := api.Manager.ExportMonitors(api.access(c))
+	if err != nil {
+		log.Error("Failed to export monitors: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := monitor.MarshalMonitors(doc, format)
+	if err != nil {
+		log.Error("Failed to export monitors: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "application/yaml"
+	if format == monitor.FormatJSON {
+		contentType = "application/json"
+	}
+	c.Header("Content-Disposition", "attachment; filename=monitors."+string(format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// importMonitors creates and updates the monitors of the document, with
+// prune=true monitors with a key missing from it are deleted. dryRun=true
+// only returns the changes.
+func (api *API) importMonitors(c *gin.Context) {
+	format, ok := specFormat(c)
+	if !ok {
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	doc, err := monitor.ParseMonitors(data, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prune := c.Query("prune") == "true"
+	if c.Query("dryRun") == "true" {
+		api.respondPlan(c, doc, prune)
+		return
+	}
+
+	plan, err := api.Manager.ApplySync(doc, prune)
+	if err != nil {
+		log.Warning("Failed to import monitors: %v", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": err.Error(),
+			"plan":  plan,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// planMonitorsFile shows what the next sync of the desired state file would
+// change.
+func (api *API) planMonitorsFile(c *gin.Context) {
+	path := api.Manager.MonitorsFile()
+	if path == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no monitors file is configured"})
+		return
+	}
+
+	doc, _, err := monitor.ReadMonitorsFile(path)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	api.respondPlan(c, doc, true)
+}
+
+func (api *API) respondPlan(c *gin.Context, doc *monitor.MonitorsDocument, prune bool) {
+	plan, err := api.Manager.PlanSync(doc, prune)
+	if err != nil {
+		log.Error("Failed to plan monitor sync: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// specFormat returns the format from the format query parameter or the
+// content type, YAML is the default.
+func specFormat(c *gin.Context) (monitor.SpecFormat, bool) {
+	switch format := c.Query("format"); format {
+	case "yaml", "yml":
+		return monitor.FormatYAML, true
+	case "json":
+		return monitor.FormatJSON, true
+	case "":
+		if strings.Contains(c.ContentType(), "json") {
+			return monitor.FormatJSON, true
+		}
+		return monitor.FormatYAML, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be yaml or json"})
+		return "", false
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"honk/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) registerUserRoutes() {
+	api.routes.GET("/users", api.listUsers)
+	api.routes.POST("/users", api.createUser)
+	api.routes.PUT("/users/:id", api.updateUser)
+	api.routes.DELETE("/users/:id", api.deleteUser)
+
+	api.routes.GET("/teams", api.listTeams)
+	api.routes.POST("/teams", api.createTeam)
+	api.routes.PUT("/teams/:id", api.updateTeam)
+	api.routes.DELETE("/teams/:id", api.deleteTeam)
+}
+
+func (api *API) listUsers(c *gin.Context) {
+	users, err := api.Auth.ListUsers()
+	if err != nil {
+		log.Error("Failed to list users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (api *API) createUser(c *gin.Context) {
+	var req NewUser
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	user, err := api.Auth.CreateUser(req.Username, req.Password, req.Role)
+	if err != nil {
+		log.Warning("Failed to create user: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.TeamIDs != nil {
+		user, err = api.Auth.UpdateUser(user.ID, auth.UserUpdate{TeamIDs: req.TeamIDs})
+		if err != nil {
+			respondUserError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (api *API) updateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req UpdateUser
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	user, err := api.Auth.UpdateUser(uint(id), req.toUserUpdate())
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (api *API) deleteUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := api.Auth.DeleteUser(uint(id)); err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (api *API) listTeams(c *gin.Context) {
+	teams, err := api.Auth.ListTeams()
+	if err != nil {
+		log.Error("Failed to list teams: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list teams"})
+		return
+	}
+
+	c.JSON(http.StatusOK, teams)
+}
+
+func (api *API) createTeam(c *gin.Context) {
+	var req NewTeam
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	team, err := api.Auth.CreateTeam(req.Name)
+	if err != nil {
+		log.Warning("Failed to create team: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+func (api *API) updateTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid team id"})
+		return
+	}
+
+	var req NewTeam
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	team, err := api.Auth.RenameTeam(uint(id), req.Name)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+func (api *API) deleteTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid team id"})
+		return
+	}
+
+	if err := api.Auth.DeleteTeam(uint(id)); err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound), errors.Is(err, auth.ErrTeamNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrTeamInUse), errors.Is(err, auth.ErrLastAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Warning("Failed to update user or team: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"net/http"
 	"time"
 
-	"honk/internal/database"
 	"honk/internal/notification"
 
 	"github.com/gin-gonic/gin"
@@ -18,7 +17,7 @@ func (api *API) testWebhook(c *gin.Context) {
 	testType := c.DefaultQuery("type", "error")
 	now := time.Now()
 
-	var req database.Notification
+	var req TestNotification
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Warning("Invalid monitor payload: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -27,10 +26,22 @@ func (api *API) testWebhook(c *gin.Context) {
 		return
 	}
 
+	req.Notification.WebhookOptions = req.WebhookOptions.toWebhookOptions()
+	if req.ID != 0 {
+		if current, err := api.Manager.GetChannel(req.ID, api.access(c)); err == nil {
+			keepCredentials(&req.Notification, current, req.WebhookOptions.ClearSecret)
+		}
+	}
+
+	notifier, err := api.Manager.Notifier(&req.Notification)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var (
-		notifier = notification.NewWebhookNotifier(req.Webhook)
-		title    = req.Template.ErrorTitle
-		body     = req.ErrorBody
+		title = req.Template.ErrorTitle
+		body  = req.ErrorBody
 	)
 
 	if testType == "success" {
@@ -54,6 +65,9 @@ func (api *API) testWebhook(c *gin.Context) {
 		},
 	}
 
-	notifier.Send(msg)
+	if err := notifier.Send(msg); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
 	c.Status(http.StatusOK)
 }
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"honk/internal/monitor"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	wsReadLimit  = 64 * 1024
+)
+
+// eventFilter limits the events sent to a client, empty fields match
+// everything. Clients can replace it at any time by sending a new one.
+type eventFilter struct {
+	MonitorIDs []uint              `json:"monitorIds"`
+	Tags       []string            `json:"tags"`
+	Types      []monitor.EventType `json:"types"`
+}
+
+func (f eventFilter) matches(event monitor.Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, event.Type) {
+		return false
+	}
+	if len(f.MonitorIDs) > 0 && !slices.Contains(f.MonitorIDs, event.MonitorID) {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		if event.Monitor == nil {
+			return false
+		}
+		return slices.ContainsFunc(f.Tags, func(tag string) bool {
+			return slices.Contains(event.Monitor.Tags, tag)
+		})
+	}
+	return true
+}
+
+func (api *API) registerWebsocketRoutes() {
+	api.routes.GET("/ws", api.handleWebsocket)
+}
+
+func (api *API) handleWebsocket(c *gin.Context) {
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid monitor id"})
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: api.checkOrigin}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Warning("Failed to upgrade websocket connection: %v", err)
+		return
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Debug("failed to close websocket connection: %v", err)
+		}
+	}()
+
+	access := api.access(c)
+
+	events, unsubscribe := api.Manager.Subscribe()
+	defer unsubscribe()
+
+	var (
+		filters = make(chan eventFilter)
+		closed  = make(chan struct{})
+		done    = make(chan struct{})
+	)
+	defer close(done)
+
+	go readEventFilters(conn, filters, closed, done)
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+
+		case filter = <-filters:
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Monitor != nil && !access.CanSee(event.Monitor) {
+				continue
+			}
+			if !filter.matches(event) {
+				continue
+			}
+
+			if err := conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				log.Debug("failed to write websocket event: %v", err)
+				return
+			}
+
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readEventFilters handles messages from the client, which are only used to
+// replace the filter, and closes closed once the connection is gone.
+func readEventFilters(conn *websocket.Conn, filters chan<- eventFilter, closed chan<- struct{}, done <-chan struct{}) {
+	defer close(closed)
+
+	conn.SetReadLimit(wsReadLimit)
+	if err := conn.SetReadDeadline(time.Now().Add(wsPongWait)); err != nil {
+		return
+	}
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var filter eventFilter
+		if err := json.Unmarshal(data, &filter); err != nil {
+			log.Debug("ignoring invalid websocket filter: %v", err)
+			continue
+		}
+
+		select {
+		case filters <- filter:
+		case <-done:
+			return
+		}
+	}
+}
+
+// parseEventFilter reads the initial filter from the query, e.g.
+// ?monitor=1&monitor=2&tag=prod&type=state_change
+func parseEventFilter(c *gin.Context) (eventFilter, error) {
+	filter := eventFilter{Tags: c.QueryArray("tag")}
+
+	for _, value := range c.QueryArray("monitor") {
+		id, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return filter, err
+		}
+		filter.MonitorIDs = append(filter.MonitorIDs, uint(id))
+	}
+
+	for _, value := range c.QueryArray("type") {
+		filter.Types = append(filter.Types, monitor.EventType(value))
+	}
+
+	return filter, nil
+}
+
+// checkOrigin only allows requests from the host serving the dashboard and,
+// while the dashboard is disabled, from its development server. Credentials
+// are sent along, so other sites must not be able to use the session.
+func (api *API) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == r.Host || (!api.Dashboard && origin == devDashboardOrigin)
+}
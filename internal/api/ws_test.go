@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name      string
+		dashboard bool
+		origin    string
+		allowed   bool
+	}{
+		{"no origin", true, "", true},
+		{"same host", true, "https://honk.example.com", true},
+		{"other host", true, "https://evil.example.com", false},
+		{"other port", true, "https://honk.example.com:8443", false},
+		{"dev server with dashboard", true, devDashboardOrigin, false},
+		{"dev server without dashboard", false, devDashboardOrigin, true},
+		{"other host without dashboard", false, "https://evil.example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &API{Dashboard: tt.dashboard}
+
+			r := httptest.NewRequest(http.MethodGet, "https://honk.example.com/api/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := api.checkOrigin(r); got != tt.allowed {
+				t.Errorf("expected %v, got %v", tt.allowed, got)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	api := newTestAPI(t)
+
+	for origin, allowed := range map[string]bool{
+		devDashboardOrigin:         true,
+		"https://evil.example.com": false,
+	} {
+		r := httptest.NewRequest(http.MethodOptions, "/api/monitor", nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		w := httptest.NewRecorder()
+		api.router.ServeHTTP(w, r)
+
+		got := w.Header().Get("Access-Control-Allow-Origin")
+		if allowed && got != origin {
+			t.Errorf("%s: expected the origin to be allowed, got %q", origin, got)
+		}
+		if !allowed && (got != "" || w.Code != http.StatusForbidden) {
+			t.Errorf("%s: expected the origin to be rejected, got %d %q", origin, w.Code, got)
+		}
+	}
+}
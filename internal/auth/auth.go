@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"honk/internal"
+	"honk/internal/database"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var log = internal.GetLogger()
+
+const (
+	DEFAULT_ADMIN_USERNAME = "admin"
+	DEFAULT_SESSION_TTL    = 7 * 24 * time.Hour
+
+	MIN_PASSWORD_LENGTH = 8
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidSession     = errors.New("invalid or expired session")
+	ErrWeakPassword       = fmt.Errorf("password must be at least %d characters", MIN_PASSWORD_LENGTH)
+)
+
+// dummyHash is compared against when a user does not exist, so unknown
+// usernames take as long to reject as wrong passwords.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("honk-dummy-password"), bcrypt.DefaultCost)
+
+type Service struct {
+	db         *gorm.DB
+	SessionTTL time.Duration
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{
+		db:         db,
+		SessionTTL: DEFAULT_SESSION_TTL,
+	}
+}
+
+// Bootstrap creates the initial admin account when no users exist yet. A
+// random password is generated and logged once when none is given.
+func (s *Service) Bootstrap(username, password string) error {
+	var count int64
+	if err := s.db.Model(&database.User{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count users: %w", err)
+	}
+	if count > 0 {
+		return s.ensureAdmin()
+	}
+
+	if username == "" {
+		username = DEFAULT_ADMIN_USERNAME
+	}
+
+	generated := password == ""
+	if generated {
+		token, err := randomToken(12)
+		if err != nil {
+			return err
+		}
+		password = token
+	}
+
+	if _, err := s.CreateUser(username, password, database.RoleAdmin); err != nil {
+		return fmt.Errorf("failed to create admin user: %w", err)
+	}
+
+	if generated {
+		log.Warning("Created admin user %q with password %q, change it after the first login", username, password)
+	} else {
+		log.Info("Created admin user %q", username)
+	}
+	return nil
+}
+
+// ensureAdmin promotes the oldest user when no admin exists, which is the case
+// for accounts created before roles were introduced.
+func (s *Service) ensureAdmin() error {
+	var count int64
+	if err := s.db.Model(&database.User{}).Where("role = ?", database.RoleAdmin).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count admins: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var user database.User
+	if err := s.db.Order("id").Limit(1).Find(&user).Error; err != nil {
+		return fmt.Errorf("failed to load users: %w", err)
+	}
+	if err := s.db.Model(&user).Update("role", database.RoleAdmin).Error; err != nil {
+		return fmt.Errorf("failed to promote user %q: %w", user.Username, err)
+	}
+
+	log.Warning("No admin user found, promoted %q to admin", user.Username)
+	return nil
+}
+
+func (s *Service) CreateUser(username, password string, role database.Role) (*database.User, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &database.User{Username: username, PasswordHash: hash, Role: role}
+	if err := s.db.Create(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Service) Authenticate(username, password string) (*database.User, error) {
+	var user database.User
+	err := s.db.Where("username = ?", username).Limit(1).Find(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if user.ID == 0 {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+// ChangePassword replaces the password of the user and ends all other
+// sessions, keepSession is the token of the session making the change.
+func (s *Service) ChangePassword(user *database.User, current, password, keepSession string) error {
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(current)) != nil {
+		return ErrInvalidCredentials
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password_hash", hash).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND token_hash <> ?", user.ID, hashToken(keepSession)).
+			Delete(&database.Session{}).Error
+	})
+}
+
+// CreateSession starts a session for the user and returns its token, which is
+// only known to the client.
+func (s *Service) CreateSession(user *database.User) (string, *database.Session, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	session := &database.Session{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.SessionTTL),
+	}
+	if err := s.db.Create(session).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	s.pruneSessions()
+	return token, session, nil
+}
+
+func (s *Service) ValidateSession(token string) (*database.User, error) {
+	if token == "" {
+		return nil, ErrInvalidSession
+	}
+
+	var session database.Session
+	err := s.db.Preload("User.Teams").
+		Where("token_hash = ? AND expires_at > ?", hashToken(token), time.Now()).
+		Limit(1).
+		Find(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	if session.ID == 0 {
+		return nil, ErrInvalidSession
+	}
+
+	return &session.User, nil
+}
+
+func (s *Service) DeleteSession(token string) error {
+	return s.db.Where("token_hash = ?", hashToken(token)).Delete(&database.Session{}).Error
+}
+
+func (s *Service) pruneSessions() {
+	if err := s.db.Where("expires_at <= ?", time.Now()).Delete(&database.Session{}).Error; err != nil {
+		log.Warning("failed to prune expired sessions: %v", err)
+	}
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < MIN_PASSWORD_LENGTH {
+		return "", ErrWeakPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"honk/internal/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/honk.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return NewService(db)
+}
+
+func TestSessions(t *testing.T) {
+	s := newTestService(t)
+
+	if _, err := s.CreateUser("weak", "short", database.RoleViewer); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("expected a short password to be rejected, got %v", err)
+	}
+
+	user, err := s.CreateUser("alice", "password123", database.RoleViewer)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	if _, err := s.Authenticate("alice", "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected a wrong password to be rejected, got %v", err)
+	}
+	if _, err := s.Authenticate("bob", "password123"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected an unknown user to be rejected, got %v", err)
+	}
+	if _, err := s.Authenticate("alice", "password123"); err != nil {
+		t.Fatalf("failed to authenticate: %v", err)
+	}
+
+	current, _, err := s.CreateSession(user)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	other, _, err := s.CreateSession(user)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	if err := s.ChangePassword(user, "wrong-password", "new-password", current); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected the current password to be required, got %v", err)
+	}
+	if err := s.ChangePassword(user, "password123", "new-password", current); err != nil {
+		t.Fatalf("failed to change password: %v", err)
+	}
+
+	if _, err := s.ValidateSession(current); err != nil {
+		t.Errorf("expected the session changing the password to remain, got %v", err)
+	}
+	if _, err := s.ValidateSession(other); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("expected other sessions to end, got %v", err)
+	}
+
+	if err := s.DeleteSession(current); err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+	if _, err := s.ValidateSession(current); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("expected a deleted session to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"honk/internal/database"
+	"slices"
+	"time"
+)
+
+const (
+	tokenPrefix = "honk_"
+
+	// LastUsedAt is only written once per interval to avoid a database write
+	// on every request
+	tokenTouchInterval = time.Minute
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid or expired API token")
+	ErrTokenNotFound = errors.New("API token not found")
+)
+
+var validScopes = []database.TokenScope{
+	database.ScopeRead,
+	database.ScopeMonitorWrite,
+	database.ScopePush,
+	database.ScopeAdmin,
+}
+
+// CreateToken creates an API token for the user and returns its value, which
+// cannot be retrieved again later.
+func (s *Service) CreateToken(user *database.User, name string, scopes []database.TokenScope, expiresAt *time.Time) (string, *database.APIToken, error) {
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("token needs at least one scope")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(validScopes, scope) {
+			return "", nil, fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", nil, fmt.Errorf("expiry must be in the future")
+	}
+
+	random, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	value := tokenPrefix + random
+
+	token := &database.APIToken{
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    value[:len(tokenPrefix)+6],
+		TokenHash: hashToken(value),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.db.Create(token).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to save API token: %w", err)
+	}
+
+	return value, token, nil
+}
+
+func (s *Service) ListTokens(user *database.User) ([]database.APIToken, error) {
+	tokens := []database.APIToken{}
+	err := s.db.Where("user_id = ?", user.ID).Order("id").Find(&tokens).Error
+	return tokens, err
+}
+
+func (s *Service) RevokeToken(user *database.User, id uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, user.ID).Delete(&database.APIToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// ValidateToken returns the token with its user and records that it was used.
+func (s *Service) ValidateToken(value string) (*database.APIToken, error) {
+	var token database.APIToken
+	err := s.db.Preload("User.Teams").Where("token_hash = ?", hashToken(value)).Limit(1).Find(&token).Error
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.ID == 0 || (token.ExpiresAt != nil && !token.ExpiresAt.After(now)) {
+		return nil, ErrInvalidToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= tokenTouchInterval {
+		token.LastUsedAt = &now
+		if err := s.db.Model(&token).Update("last_used_at", now).Error; err != nil {
+			log.Warning("failed to update last use of API token %d: %v", token.ID, err)
+		}
+	}
+
+	return &token, nil
+}
+
+// Allows reports whether the token grants the scope. Admin tokens allow
+// everything and monitor write tokens can also read.
+func Allows(token *database.APIToken, scope database.TokenScope) bool {
+	for _, granted := range token.Scopes {
+		switch {
+		case granted == scope, granted == database.ScopeAdmin:
+			return true
+		case granted == database.ScopeMonitorWrite && scope == database.ScopeRead:
+			return true
+		}
+	}
+	return false
+}
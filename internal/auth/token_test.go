@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"honk/internal/database"
+)
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		granted []database.TokenScope
+		scope   database.TokenScope
+		want    bool
+	}{
+		{[]database.TokenScope{database.ScopeRead}, database.ScopeRead, true},
+		{[]database.TokenScope{database.ScopeRead}, database.ScopeMonitorWrite, false},
+		{[]database.TokenScope{database.ScopeRead}, database.ScopePush, false},
+		{[]database.TokenScope{database.ScopeMonitorWrite}, database.ScopeRead, true},
+		{[]database.TokenScope{database.ScopeMonitorWrite}, database.ScopePush, false},
+		{[]database.TokenScope{database.ScopeMonitorWrite}, database.ScopeAdmin, false},
+		{[]database.TokenScope{database.ScopePush}, database.ScopeRead, false},
+		{[]database.TokenScope{database.ScopePush}, database.ScopePush, true},
+		{[]database.TokenScope{database.ScopeRead, database.ScopePush}, database.ScopePush, true},
+		{[]database.TokenScope{database.ScopeAdmin}, database.ScopePush, true},
+		{[]database.TokenScope{database.ScopeAdmin}, database.ScopeAdmin, true},
+	}
+
+	for _, tt := range tests {
+		token := &database.APIToken{Scopes: tt.granted}
+		if got := Allows(token, tt.scope); got != tt.want {
+			t.Errorf("%v allows %q: expected %v, got %v", tt.granted, tt.scope, tt.want, got)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	s := newTestService(t)
+
+	owner, err := s.CreateUser("owner", "password123", database.RoleEditor)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	other, err := s.CreateUser("other", "password123", database.RoleEditor)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	invalid := []struct {
+		name      string
+		scopes    []database.TokenScope
+		expiresAt *time.Time
+	}{
+		{"without scopes", nil, nil},
+		{"unknown scope", []database.TokenScope{"everything"}, nil},
+		{"expired", []database.TokenScope{database.ScopeRead}, &past},
+	}
+	for _, tt := range invalid {
+		if _, _, err := s.CreateToken(owner, tt.name, tt.scopes, tt.expiresAt); err == nil {
+			t.Errorf("%s: expected the token to be rejected", tt.name)
+		}
+	}
+
+	value, token, err := s.CreateToken(owner, "ci", []database.TokenScope{database.ScopePush}, nil)
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	if token.TokenHash == value || token.Prefix != value[:len(token.Prefix)] {
+		t.Errorf("expected only the hash and prefix of the value to be stored")
+	}
+
+	validated, err := s.ValidateToken(value)
+	if err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	if validated.User.ID != owner.ID || validated.LastUsedAt == nil {
+		t.Errorf("expected the token of the owner marked as used, got user %d", validated.User.ID)
+	}
+	if _, err := s.ValidateToken(value + "x"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected an unknown value to be rejected, got %v", err)
+	}
+
+	if err := s.RevokeToken(other, token.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected tokens of other users to be hidden, got %v", err)
+	}
+	if err := s.RevokeToken(owner, token.ID); err != nil {
+		t.Fatalf("failed to revoke token: %v", err)
+	}
+	if _, err := s.ValidateToken(value); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a revoked token to be rejected, got %v", err)
+	}
+
+	soon := time.Now().Add(time.Hour)
+	value, token, err = s.CreateToken(owner, "expiring", []database.TokenScope{database.ScopeRead}, &soon)
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	s.db.Model(token).Update("expires_at", past)
+	if _, err := s.ValidateToken(value); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected an expired token to be rejected, got %v", err)
+	}
+}
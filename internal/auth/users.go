@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"honk/internal/database"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrTeamNotFound = errors.New("team not found")
+	ErrTeamInUse    = errors.New("team still owns monitors or notification channels")
+	ErrLastAdmin    = errors.New("at least one admin is required")
+)
+
+// roles in order of increasing privilege, each role includes the ones before
+var roles = []database.Role{
+	database.RoleViewer,
+	database.RoleOperator,
+	database.RoleEditor,
+	database.RoleAdmin,
+}
+
+func ValidRole(role database.Role) bool {
+	return slices.Contains(roles, role)
+}
+
+// HasRole reports whether the user has at least the given role.
+func HasRole(user *database.User, role database.Role) bool {
+	return slices.Index(roles, user.Role) >= slices.Index(roles, role)
+}
+
+// TeamIDs returns the ids of the teams the user belongs to.
+func TeamIDs(user *database.User) []uint {
+	ids := make([]uint, 0, len(user.Teams))
+	for _, team := range user.Teams {
+		ids = append(ids, team.ID)
+	}
+	return ids
+}
+
+// UserUpdate holds the changes to a user, nil fields are left unchanged.
+type UserUpdate struct {
+	Password *string
+	Role     *database.Role
+	TeamIDs  []uint
+}
+
+func (s *Service) ListUsers() ([]database.User, error) {
+	users := []database.User{}
+	err := s.db.Preload("Teams").Order("id").Find(&users).Error
+	return users, err
+}
+
+func (s *Service) GetUser(id uint) (*database.User, error) {
+	var user database.User
+	if err := s.db.Preload("Teams").Limit(1).Find(&user, id).Error; err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// UpdateUser applies the changes and returns the updated user. Changing the
+// password ends all sessions of the user.
+func (s *Service) UpdateUser(id uint, update UserUpdate) (*database.User, error) {
+	user, err := s.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Role != nil {
+		if !ValidRole(*update.Role) {
+			return nil, fmt.Errorf("unknown role %q", *update.Role)
+		}
+		if user.Role == database.RoleAdmin && *update.Role != database.RoleAdmin {
+			if err := s.requireOtherAdmin(user.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if update.Password != nil {
+			hash, err := hashPassword(*update.Password)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(user).Update("password_hash", hash).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("user_id = ?", user.ID).Delete(&database.Session{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if update.Role != nil {
+			if err := tx.Model(user).Update("role", *update.Role).Error; err != nil {
+				return err
+			}
+		}
+
+		if update.TeamIDs != nil {
+			teams, err := findTeams(tx, update.TeamIDs)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(user).Association("Teams").Replace(teams); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(id)
+}
+
+func (s *Service) DeleteUser(id uint) error {
+	user, err := s.GetUser(id)
+	if err != nil {
+		return err
+	}
+	if user.Role == database.RoleAdmin {
+		if err := s.requireOtherAdmin(user.ID); err != nil {
+			return err
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Association("Teams").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&database.Session{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&database.APIToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(user).Error
+	})
+}
+
+func (s *Service) requireOtherAdmin(id uint) error {
+	var count int64
+	err := s.db.Model(&database.User{}).Where("role = ? AND id <> ?", database.RoleAdmin, id).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func (s *Service) ListTeams() ([]database.Team, error) {
+	teams := []database.Team{}
+	err := s.db.Order("id").Find(&teams).Error
+	return teams, err
+}
+
+func (s *Service) CreateTeam(name string) (*database.Team, error) {
+	if name == "" {
+		return nil, fmt.Errorf("team name is required")
+	}
+
+	team := &database.Team{Name: name}
+	if err := s.db.Create(team).Error; err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+func (s *Service) RenameTeam(id uint, name string) (*database.Team, error) {
+	if name == "" {
+		return nil, fmt.Errorf("team name is required")
+	}
+
+	teams, err := findTeams(s.db, []uint{id})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&teams[0]).Update("name", name).Error; err != nil {
+		return nil, err
+	}
+	return &teams[0], nil
+}
+
+// DeleteTeam removes the team, teams that still own monitors or notification
+// channels are kept so those do not lose their owner.
+func (s *Service) DeleteTeam(id uint) error {
+	teams, err := findTeams(s.db, []uint{id})
+	if err != nil {
+		return err
+	}
+
+	for _, model := range []any{&database.Monitor{}, &database.Notification{}} {
+		var count int64
+		if err := s.db.Model(model).Where("team_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrTeamInUse
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM team_members WHERE team_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&teams[0]).Error
+	})
+}
+
+func findTeams(db *gorm.DB, ids []uint) ([]database.Team, error) {
+	teams := []database.Team{}
+	if len(ids) == 0 {
+		return teams, nil
+	}
+	if err := db.Find(&teams, ids).Error; err != nil {
+		return nil, err
+	}
+	if len(teams) != len(ids) {
+		return nil, ErrTeamNotFound
+	}
+	return teams, nil
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"honk/internal/database"
+)
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		role     database.Role
+		required database.Role
+		want     bool
+	}{
+		{database.RoleViewer, database.RoleViewer, true},
+		{database.RoleViewer, database.RoleOperator, false},
+		{database.RoleOperator, database.RoleViewer, true},
+		{database.RoleOperator, database.RoleEditor, false},
+		{database.RoleEditor, database.RoleOperator, true},
+		{database.RoleEditor, database.RoleAdmin, false},
+		{database.RoleAdmin, database.RoleEditor, true},
+		{"unknown", database.RoleViewer, false},
+	}
+
+	for _, tt := range tests {
+		if got := HasRole(&database.User{Role: tt.role}, tt.required); got != tt.want {
+			t.Errorf("%q has %q: expected %v, got %v", tt.role, tt.required, tt.want, got)
+		}
+	}
+}
+
+func TestLastAdmin(t *testing.T) {
+	s := newTestService(t)
+
+	admin, err := s.CreateUser("admin", "password123", database.RoleAdmin)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	viewer := database.RoleViewer
+	if _, err := s.UpdateUser(admin.ID, UserUpdate{Role: &viewer}); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected the last admin to keep the role, got %v", err)
+	}
+	if err := s.DeleteUser(admin.ID); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected the last admin to be kept, got %v", err)
+	}
+
+	if _, err := s.CreateUser("second", "password123", database.RoleAdmin); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if _, err := s.UpdateUser(admin.ID, UserUpdate{Role: &viewer}); err != nil {
+		t.Errorf("expected an admin to be demoted while another remains, got %v", err)
+	}
+}
+
+func TestTeams(t *testing.T) {
+	s := newTestService(t)
+
+	team, err := s.CreateTeam("ops")
+	if err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	user, err := s.CreateUser("alice", "password123", database.RoleEditor)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	if _, err := s.UpdateUser(user.ID, UserUpdate{TeamIDs: []uint{team.ID, team.ID + 1}}); !errors.Is(err, ErrTeamNotFound) {
+		t.Errorf("expected unknown teams to be rejected, got %v", err)
+	}
+	user, err = s.UpdateUser(user.ID, UserUpdate{TeamIDs: []uint{team.ID}})
+	if err != nil {
+		t.Fatalf("failed to update user: %v", err)
+	}
+	if ids := TeamIDs(user); len(ids) != 1 || ids[0] != team.ID {
+		t.Errorf("expected the user in team %d, got %v", team.ID, ids)
+	}
+
+	owned := []any{
+		&database.Monitor{Name: "api", TeamID: &team.ID},
+		&database.Notification{Name: "ops", TeamID: &team.ID},
+	}
+	for _, row := range owned {
+		if err := s.db.Create(row).Error; err != nil {
+			t.Fatalf("failed to save %T: %v", row, err)
+		}
+		if err := s.DeleteTeam(team.ID); !errors.Is(err, ErrTeamInUse) {
+			t.Errorf("expected a team owning a %T to be kept, got %v", row, err)
+		}
+		if err := s.db.Delete(row).Error; err != nil {
+			t.Fatalf("failed to delete %T: %v", row, err)
+		}
+	}
+
+	if err := s.DeleteTeam(team.ID); err != nil {
+		t.Fatalf("failed to delete team: %v", err)
+	}
+	if user, _ = s.GetUser(user.ID); len(user.Teams) != 0 {
+		t.Errorf("expected the members to leave the deleted team, got %v", TeamIDs(user))
+	}
+}
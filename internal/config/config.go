@@ -0,0 +1,298 @@
+// Package config loads the runtime configuration. Values are applied in order
+// of increasing precedence: built-in defaults, the config file, HONK_*
+// environment variables and finally command line flags.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"honk/internal"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	DEFAULT_LISTEN   = ":8080"
+	DEFAULT_DATA_DIR = "data"
+	DEFAULT_TIMEOUT  = 5 * time.Second
+	ENV_PREFIX       = "HONK_"
+)
+
+type Config struct {
+	Listen    string          `yaml:"listen" toml:"listen"`
+	TLS       TLSConfig       `yaml:"tls" toml:"tls"`
+	DataDir   string          `yaml:"data_dir" toml:"data_dir"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Timeouts  TimeoutConfig   `yaml:"timeouts" toml:"timeouts"`
+	Retention RetentionConfig `yaml:"retention" toml:"retention"`
+	Features  FeatureConfig   `yaml:"features" toml:"features"`
+	Admin     AdminConfig     `yaml:"admin" toml:"admin"`
+	SMTP      SMTPConfig      `yaml:"smtp" toml:"smtp"`
+
+	// Desired state of the monitors, kept in sync while honk runs
+	MonitorsFile string `yaml:"monitors_file" toml:"monitors_file"`
+}
+
+// TLSConfig serves the dashboard and API over HTTPS when both files are set.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile  string `yaml:"key_file" toml:"key_file"`
+}
+
+type DatabaseConfig struct {
+	// DSN of the SQLite database, defaults to database.db in the data directory
+	DSN string `yaml:"dsn" toml:"dsn"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level"`   // debug, info, warning or error
+	Format string `yaml:"format" toml:"format"` // text or json
+}
+
+// TimeoutConfig holds the default check timeouts of the monitor types, HTTP
+// monitors can override theirs per monitor.
+type TimeoutConfig struct {
+	HTTP      Duration `yaml:"http" toml:"http"`
+	ICMP      Duration `yaml:"icmp" toml:"icmp"`
+	TCP       Duration `yaml:"tcp" toml:"tcp"`
+	TLS       Duration `yaml:"tls" toml:"tls"`
+	DNS       Duration `yaml:"dns" toml:"dns"`
+	Container Duration `yaml:"container" toml:"container"`
+}
+
+// RetentionConfig holds the days check data is kept per resolution, 0 keeps
+// that resolution forever.
+type RetentionConfig struct {
+	RawDays    int `yaml:"raw_days" toml:"raw_days"`
+	HourlyDays int `yaml:"hourly_days" toml:"hourly_days"`
+	DailyDays  int `yaml:"daily_days" toml:"daily_days"`
+}
+
+type FeatureConfig struct {
+	Dashboard      bool `yaml:"dashboard" toml:"dashboard"`
+	Authentication bool `yaml:"auth" toml:"auth"`
+	Metrics        bool `yaml:"metrics" toml:"metrics"`
+	Containers     bool `yaml:"containers" toml:"containers"`
+}
+
+// AdminConfig is only used to create the first user when authentication is
+// enabled.
+type AdminConfig struct {
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+}
+
+// SMTPConfig is the mail server used by all email notification channels.
+type SMTPConfig struct {
+	Host     string   `yaml:"host" toml:"host"`
+	Port     int      `yaml:"port" toml:"port"`
+	Username string   `yaml:"username" toml:"username"`
+	Password string   `yaml:"password" toml:"password"`
+	From     string   `yaml:"from" toml:"from"`
+	Security string   `yaml:"security" toml:"security"` // starttls, tls or none
+	Timeout  Duration `yaml:"timeout" toml:"timeout"`
+}
+
+// Duration accepts values like "5s" or "1m30s" in config files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(value)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func Default() *Config {
+	return &Config{
+		Listen:  DEFAULT_LISTEN,
+		DataDir: DEFAULT_DATA_DIR,
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Timeouts: TimeoutConfig{
+			HTTP:      Duration(30 * time.Second),
+			ICMP:      Duration(DEFAULT_TIMEOUT),
+			TCP:       Duration(DEFAULT_TIMEOUT),
+			TLS:       Duration(DEFAULT_TIMEOUT),
+			DNS:       Duration(DEFAULT_TIMEOUT),
+			Container: Duration(DEFAULT_TIMEOUT),
+		},
+		Retention: RetentionConfig{
+			RawDays:    30,
+			HourlyDays: 180,
+		},
+		Features: FeatureConfig{
+			Metrics:    true,
+			Containers: true,
+		},
+		SMTP: SMTPConfig{
+			Port:     587,
+			Security: "starttls",
+			Timeout:  Duration(10 * time.Second),
+		},
+	}
+}
+
+// Load builds the configuration from the command line arguments, without the
+// program name, and the environment. The config file is given with -config
+// or HONK_CONFIG.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	var configFile string
+	fs := flag.NewFlagSet("honk", flag.ContinueOnError)
+	fs.StringVar(&configFile, "config", os.Getenv(ENV_PREFIX+"CONFIG"), "path to a YAML or TOML config file")
+
+	options := cfg.options()
+	for _, opt := range options {
+		fs.Var(opt.value, opt.name, opt.usage)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Flags are parsed first to find the config file, their values are
+	// applied again last so they take precedence
+	flags := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+	*cfg = *Default()
+
+	if configFile != "" {
+		if err := cfg.loadFile(configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, opt := range options {
+		value, ok := os.LookupEnv(opt.env())
+		if !ok {
+			continue
+		}
+		if err := opt.value.Set(value); err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", opt.env(), err)
+		}
+	}
+
+	for _, opt := range options {
+		if value, ok := flags[opt.name]; ok {
+			if err := opt.value.Set(value); err != nil {
+				return nil, fmt.Errorf("invalid value for -%s: %w", opt.name, err)
+			}
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// DatabaseDSN returns the configured DSN or the default database file in the
+// data directory.
+func (c *Config) DatabaseDSN() string {
+	if c.Database.DSN != "" {
+		return c.Database.DSN
+	}
+	return filepath.Join(c.DataDir, "database.db")
+}
+
+func (c *Config) TLSEnabled() bool {
+	return c.TLS.CertFile != "" && c.TLS.KeyFile != ""
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalWithOptions(data, c, yaml.Strict())
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(c)
+	default:
+		return fmt.Errorf("unsupported config file %q, expected .yaml, .yml or .toml", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) validate() error {
+	var errs []error
+
+	if c.Listen == "" {
+		errs = append(errs, errors.New("listen address is required"))
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("tls needs both a certificate and a key file"))
+	}
+	if c.DataDir == "" {
+		errs = append(errs, errors.New("data directory is required"))
+	}
+
+	if _, err := internal.ParseLogLevel(c.Log.Level); err != nil {
+		errs = append(errs, err)
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("unknown log format %q", c.Log.Format))
+	}
+
+	for name, timeout := range map[string]Duration{
+		"http":      c.Timeouts.HTTP,
+		"icmp":      c.Timeouts.ICMP,
+		"tcp":       c.Timeouts.TCP,
+		"tls":       c.Timeouts.TLS,
+		"dns":       c.Timeouts.DNS,
+		"container": c.Timeouts.Container,
+	} {
+		if timeout <= 0 {
+			errs = append(errs, fmt.Errorf("%s timeout must be positive", name))
+		}
+	}
+
+	switch c.SMTP.Security {
+	case "starttls", "tls", "none":
+	default:
+		errs = append(errs, fmt.Errorf("unknown smtp security %q, expected starttls, tls or none", c.SMTP.Security))
+	}
+	if c.SMTP.Host != "" && c.SMTP.From == "" {
+		errs = append(errs, errors.New("smtp needs a from address"))
+	}
+	if c.SMTP.Port <= 0 || c.SMTP.Port > 65535 {
+		errs = append(errs, fmt.Errorf("invalid smtp port %d", c.SMTP.Port))
+	}
+	if c.SMTP.Timeout <= 0 {
+		errs = append(errs, errors.New("smtp timeout must be positive"))
+	}
+
+	if c.Retention.RawDays < 0 || c.Retention.HourlyDays < 0 || c.Retention.DailyDays < 0 {
+		errs = append(errs, errors.New("retention days cannot be negative"))
+	}
+
+	return errors.Join(errs...)
+}
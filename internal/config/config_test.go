@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeConfig(t, "honk.yaml", `
+listen: ":9000"
+data_dir: /var/lib/honk
+log:
+  level: debug
+timeouts:
+  http: 10s
+features:
+  auth: true
+`)
+	tomlFile := writeConfig(t, "honk.toml", `
+listen = ":9100"
+
+[timeouts]
+http = "15s"
+`)
+
+	tests := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Listen != DEFAULT_LISTEN || cfg.DataDir != DEFAULT_DATA_DIR || time.Duration(cfg.Timeouts.HTTP) != 30*time.Second {
+					t.Errorf("expected the defaults, got %q %q %v", cfg.Listen, cfg.DataDir, cfg.Timeouts.HTTP)
+				}
+			},
+		},
+		{
+			name: "file over defaults",
+			args: []string{"-config", yamlFile},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Listen != ":9000" || cfg.Log.Level != "debug" || !cfg.Features.Authentication {
+					t.Errorf("expected the values of the file, got %q %q %v", cfg.Listen, cfg.Log.Level, cfg.Features.Authentication)
+				}
+				// Values left out of the file keep their defaults
+				if cfg.Log.Format != "text" || cfg.Retention.RawDays != 30 {
+					t.Errorf("expected defaults for other values, got %q %d", cfg.Log.Format, cfg.Retention.RawDays)
+				}
+			},
+		},
+		{
+			name: "toml file",
+			env:  map[string]string{"HONK_CONFIG": tomlFile},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Listen != ":9100" || time.Duration(cfg.Timeouts.HTTP) != 15*time.Second {
+					t.Errorf("expected the values of the toml file, got %q %v", cfg.Listen, cfg.Timeouts.HTTP)
+				}
+			},
+		},
+		{
+			name: "environment over file",
+			args: []string{"-config", yamlFile},
+			env:  map[string]string{"HONK_LISTEN": ":9200", "HONK_AUTH": "false"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Listen != ":9200" || cfg.Features.Authentication {
+					t.Errorf("expected the environment to win, got %q %v", cfg.Listen, cfg.Features.Authentication)
+				}
+				if cfg.Log.Level != "debug" {
+					t.Errorf("expected the file value without environment variable, got %q", cfg.Log.Level)
+				}
+			},
+		},
+		{
+			name: "flags over environment",
+			args: []string{"-config", yamlFile, "-listen", ":9300", "-timeout-http", "20s"},
+			env:  map[string]string{"HONK_LISTEN": ":9200", "HONK_TIMEOUT_HTTP": "5s", "HONK_LOG_LEVEL": "warning"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Listen != ":9300" || time.Duration(cfg.Timeouts.HTTP) != 20*time.Second {
+					t.Errorf("expected the flags to win, got %q %v", cfg.Listen, cfg.Timeouts.HTTP)
+				}
+				if cfg.Log.Level != "warning" {
+					t.Errorf("expected the environment without flag, got %q", cfg.Log.Level)
+				}
+			},
+		},
+		{
+			name: "flag set to the default still wins",
+			args: []string{"-listen", DEFAULT_LISTEN},
+			env:  map[string]string{"HONK_LISTEN": ":9200"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Listen != DEFAULT_LISTEN {
+					t.Errorf("expected the flag to win, got %q", cfg.Listen)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		err  string
+	}{
+		{"unknown file field", []string{"-config", writeConfig(t, "honk.yaml", "listn: \":9000\"\n")}, nil, "failed to parse config file"},
+		{"unsupported file", []string{"-config", writeConfig(t, "honk.json", "{}")}, nil, "unsupported config file"},
+		{"invalid environment", nil, map[string]string{"HONK_SMTP_PORT": "mail"}, "HONK_SMTP_PORT"},
+		{"invalid flag", []string{"-timeout-http", "soon"}, nil, "invalid value"},
+		{"invalid value", []string{"-log-format", "xml"}, nil, "unknown log format"},
+		{"half of tls", []string{"-tls-cert", "cert.pem"}, nil, "tls needs both"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := Load(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
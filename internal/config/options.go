@@ -0,0 +1,110 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// option binds a config field to a command line flag and an environment
+// variable named after the flag, e.g. -log-level and HONK_LOG_LEVEL.
+type option struct {
+	name  string
+	usage string
+	value value
+}
+
+type value interface {
+	String() string
+	Set(string) error
+}
+
+func (o option) env() string {
+	return ENV_PREFIX + strings.ToUpper(strings.ReplaceAll(o.name, "-", "_"))
+}
+
+func (c *Config) options() []option {
+	return []option{
+		{"listen", "address the web server listens on", (*stringValue)(&c.Listen)},
+		{"tls-cert", "TLS certificate file", (*stringValue)(&c.TLS.CertFile)},
+		{"tls-key", "TLS private key file", (*stringValue)(&c.TLS.KeyFile)},
+		{"data-dir", "directory for the database and other data", (*stringValue)(&c.DataDir)},
+		{"database-dsn", "SQLite DSN, defaults to database.db in the data directory", (*stringValue)(&c.Database.DSN)},
+		{"monitors-file", "YAML or JSON file with the desired monitors, synced on startup and on change", (*stringValue)(&c.MonitorsFile)},
+		{"log-level", "log level: debug, info, warning or error", (*stringValue)(&c.Log.Level)},
+		{"log-format", "log format: text or json", (*stringValue)(&c.Log.Format)},
+		{"timeout-http", "default timeout of http checks", (*durationValue)(&c.Timeouts.HTTP)},
+		{"timeout-icmp", "timeout of ping checks", (*durationValue)(&c.Timeouts.ICMP)},
+		{"timeout-tcp", "timeout of tcp checks", (*durationValue)(&c.Timeouts.TCP)},
+		{"timeout-tls", "timeout of tls checks", (*durationValue)(&c.Timeouts.TLS)},
+		{"timeout-dns", "timeout of dns checks", (*durationValue)(&c.Timeouts.DNS)},
+		{"timeout-container", "timeout when connecting to docker", (*durationValue)(&c.Timeouts.Container)},
+		{"retention-raw-days", "days raw checks are kept, 0 keeps them forever", (*intValue)(&c.Retention.RawDays)},
+		{"retention-hourly-days", "days hourly rollups are kept, 0 keeps them forever", (*intValue)(&c.Retention.HourlyDays)},
+		{"retention-daily-days", "days daily rollups are kept, 0 keeps them forever", (*intValue)(&c.Retention.DailyDays)},
+		{"dashboard", "serve the dashboard", (*boolValue)(&c.Features.Dashboard)},
+		{"auth", "require login for the dashboard and API", (*boolValue)(&c.Features.Authentication)},
+		{"metrics", "expose Prometheus metrics on /metrics", (*boolValue)(&c.Features.Metrics)},
+		{"containers", "enable docker container monitors", (*boolValue)(&c.Features.Containers)},
+		{"admin-username", "username of the initial admin", (*stringValue)(&c.Admin.Username)},
+		{"admin-password", "password of the initial admin, generated when empty", (*stringValue)(&c.Admin.Password)},
+		{"smtp-host", "mail server used by email notifications", (*stringValue)(&c.SMTP.Host)},
+		{"smtp-port", "port of the mail server", (*intValue)(&c.SMTP.Port)},
+		{"smtp-username", "username for the mail server, no authentication when empty", (*stringValue)(&c.SMTP.Username)},
+		{"smtp-password", "password for the mail server", (*stringValue)(&c.SMTP.Password)},
+		{"smtp-from", "sender address of email notifications", (*stringValue)(&c.SMTP.From)},
+		{"smtp-security", "connection security: starttls, tls or none", (*stringValue)(&c.SMTP.Security)},
+		{"smtp-timeout", "timeout when sending email", (*durationValue)(&c.SMTP.Timeout)},
+	}
+}
+
+type stringValue string
+
+func (v *stringValue) String() string { return string(*v) }
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(n)
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+// IsBoolFlag allows -dashboard instead of -dashboard=true.
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+type durationValue Duration
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(d)
+	return nil
+}
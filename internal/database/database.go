@@ -1,21 +1,25 @@
 package database
 
 import (
+	"cmp"
+	"fmt"
+	"honk/internal/notification"
 	"log"
+	"net/url"
 	"os"
-	"path/filepath"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 )
 
-func Initialize() *gorm.DB {
-	if err := os.MkdirAll("data", 0755); err != nil {
+// Initialize opens the database at dsn, creating the data directory which
+// holds the database file by default.
+func Initialize(dataDir, dsn string) *gorm.DB {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		log.Fatal("failed to create data directory: %w", err)
 	}
 
-	databasePath := filepath.Join("data", "database.db")
-	db, err := gorm.Open(sqlite.Open(databasePath), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		log.Fatal("failed while initializing database: %w", err)
 	}
@@ -28,10 +32,131 @@ func Initialize() *gorm.DB {
 }
 
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
 		&Monitor{},
 		&MonitorCheck{},
+		&MonitorCheckRollup{},
+		&Incident{},
 		&Notification{},
+		&MonitorNotification{},
 		&HttpMonitorHeader{},
+		&HttpMonitorAssertion{},
+		&MaintenanceWindow{},
+		&Team{},
+		&User{},
+		&Session{},
+		&APIToken{},
 	)
+	if err != nil {
+		return err
+	}
+
+	// Checks saved before maintenance windows existed have no value, they
+	// were not taken during maintenance
+	if err := db.Model(&MonitorCheck{}).Where("maintenance IS NULL").Update("maintenance", false).Error; err != nil {
+		return err
+	}
+
+	return migrateNotifications(db)
+}
+
+// legacyNotification holds the notification settings of a single monitor,
+// stored before channels could be shared.
+type legacyNotification struct {
+	ID        uint
+	MonitorID uint
+	Enabled   bool
+	Type      string
+	Webhook   string
+	Email     string
+	Template
+}
+
+func (legacyNotification) TableName() string {
+	return "notifications"
+}
+
+// migrateNotifications turns the per monitor notification settings into
+// channels, monitors with identical settings share one channel.
+func migrateNotifications(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&legacyNotification{}) {
+		return nil
+	}
+
+	var legacy []legacyNotification
+	if err := db.Order("id").Find(&legacy).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var (
+			channels = map[legacyNotification]uint{}
+			names    = map[string]bool{}
+		)
+
+		for _, l := range legacy {
+			if l.Type == "" && l.Webhook == "" && l.Email == "" {
+				continue
+			}
+
+			settings := l
+			settings.ID, settings.MonitorID = 0, 0
+
+			id, ok := channels[settings]
+			if !ok {
+				channel := Notification{
+					Name:     channelName(l, names),
+					Enabled:  l.Enabled,
+					Type:     legacyType(l),
+					Webhook:  l.Webhook,
+					Email:    l.Email,
+					Template: l.Template,
+				}
+				if err := tx.Create(&channel).Error; err != nil {
+					return err
+				}
+				id = channel.ID
+				channels[settings] = id
+			}
+
+			link := MonitorNotification{MonitorID: l.MonitorID, NotificationID: id, Events: DefaultNotificationEvents}
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+		}
+
+		log.Printf("migrated %d notification settings into %d channels", len(legacy), len(channels))
+		return tx.Migrator().DropTable(&legacyNotification{})
+	})
+}
+
+// legacyType resolves the platform of a legacy webhook from its URL as it
+// used to be done on every send, unknown URLs become generic webhooks.
+func legacyType(l legacyNotification) string {
+	if l.Webhook == "" {
+		return l.Type
+	}
+	if platform := notification.DetectPlatform(l.Webhook); platform != "" {
+		return string(platform)
+	}
+	return string(notification.Webhook)
+}
+
+// channelName names a migrated channel after the host of its webhook or its
+// email address.
+func channelName(l legacyNotification, used map[string]bool) string {
+	base := l.Email
+	if u, err := url.Parse(l.Webhook); err == nil && u.Host != "" {
+		base = u.Host
+	}
+	if base == "" {
+		base = cmp.Or(l.Type, "notification")
+	}
+
+	name := base
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s (%d)", base, i)
+	}
+	used[name] = true
+	return name
 }
@@ -1,6 +1,10 @@
 package database
 
-import "time"
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
 
 type ConnectionType string
 
@@ -9,10 +13,43 @@ const (
 	ConnectionTypePing      ConnectionType = "ping"
 	ConnectionTypeContainer ConnectionType = "container"
 	ConnectionTypeTCP       ConnectionType = "tcp"
+	ConnectionTypeTLS       ConnectionType = "tls"
+	ConnectionTypeDNS       ConnectionType = "dns"
+	ConnectionTypePush      ConnectionType = "push"
+)
+
+type DNSMatchMode string
+
+const (
+	DNSMatchNonEmpty DNSMatchMode = "non_empty"
+	DNSMatchExact    DNSMatchMode = "exact"
+	DNSMatchContains DNSMatchMode = "contains"
+)
+
+type MonitorStatus string
+
+const (
+	StatusUnknown MonitorStatus = ""
+	StatusUp      MonitorStatus = "up"
+	StatusDown    MonitorStatus = "down"
+	StatusPending MonitorStatus = "pending"
+
+	// A monitor is unreachable while it fails because a parent is down
+	StatusUnreachable MonitorStatus = "unreachable"
+)
+
+type BodyType string
+
+const (
+	BodyTypeNone BodyType = ""
+	BodyTypeRaw  BodyType = "raw"
+	BodyTypeJSON BodyType = "json"
+	BodyTypeForm BodyType = "form"
 )
 
 type Monitor struct {
 	ID               uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	Key              string         `gorm:"index" json:"key"` // stable identifier for monitors managed as code
 	Enabled          bool           `json:"enabled"`
 	Name             string         `json:"name"`
 	Connection       string         `json:"connection"`
@@ -20,42 +57,216 @@ type Monitor struct {
 	HTTPMethod       string         `json:"httpMethod"`
 	Timeout          int            `json:"timeout"`
 	Body             string         `json:"body"`
+	BodyType         BodyType       `json:"bodyType"`
+	ContentType      string         `json:"contentType"`
 	Interval         int            `json:"interval"`
 	Healthy          *bool          `json:"healthy"` // nil if unknown
+	Status           MonitorStatus  `json:"status"`
 	AlwaysSave       bool           `json:"alwaysSave"`
 	Checked          time.Time      `json:"checked,omitzero"`
 	Result           string         `json:"result"`
+	ResponseTimeMs   int64          `json:"responseTimeMs"`
 	TotalChecks      int            `json:"totalChecks"`
 	SuccessfulChecks int            `json:"successfulChecks"`
+	CreatedAt        time.Time      `json:"createdAt,omitzero"`
+	Tags             []string       `gorm:"serializer:json" json:"tags"`
+	InMaintenance    bool           `gorm:"-" json:"inMaintenance"`
+	ParentIDs        []uint         `gorm:"serializer:json" json:"parentIds"`
+	TeamID           *uint          `gorm:"index" json:"teamId"` // nil if shared with everyone
+
+	// Confirmation before the monitor changes state, Healthy only flips after
+	// RetriesBeforeDown extra failures or SuccessesBeforeUp successes
+	RetriesBeforeDown    int `json:"retriesBeforeDown"`
+	RetryInterval        int `json:"retryInterval"`
+	SuccessesBeforeUp    int `json:"successesBeforeUp"`
+	ConsecutiveFailures  int `json:"consecutiveFailures"`
+	ConsecutiveSuccesses int `json:"consecutiveSuccesses"`
+
+	// Reminders are sent every ReminderInterval seconds while the monitor is down
+	ReminderInterval int        `json:"reminderInterval"`
+	RemindersSent    int        `json:"remindersSent"`
+	LastReminder     *time.Time `json:"lastReminder,omitempty"`
+	DownSince        *time.Time `json:"downSince,omitempty"`
+
+	// Certificate expiry checks for tls and https monitors
+	CertExpiryDays     int        `json:"certExpiryDays"`
+	CertExpiryWarnOnly bool       `json:"certExpiryWarnOnly"`
+	CertExpiresAt      *time.Time `json:"certExpiresAt,omitempty"`
+
+	// DNS record checks, the connection holds the name to resolve
+	DNSRecordType string       `json:"dnsRecordType"`
+	DNSResolver   string       `json:"dnsResolver"`
+	DNSMatch      DNSMatchMode `json:"dnsMatch"`
+	DNSExpected   string       `json:"dnsExpected"`
+	DNSMinTTL     int          `json:"dnsMinTTL"`
+	DNSMaxTTL     int          `json:"dnsMaxTTL"`
+
+	// Docker container checks, the connection holds the container name or ID
+	ContainerFailOnUnhealthy bool `json:"containerFailOnUnhealthy"`
+	ContainerMaxRestarts     int  `json:"containerMaxRestarts"`
+	ContainerRestartWindow   int  `json:"containerRestartWindow"`
+
+	// ICMP ping checks, fails when packet loss reaches the threshold (percent)
+	PingCount         int `json:"pingCount"`
+	PingLossThreshold int `json:"pingLossThreshold"`
+
+	// Push monitors are marked down when no ping arrives within interval + grace period
+	PushToken     string     `gorm:"index" json:"pushToken,omitempty"`
+	GracePeriod   int        `json:"gracePeriod"`
+	PushLastPing  time.Time  `json:"pushLastPing,omitzero"`
+	PushStartedAt *time.Time `json:"pushStartedAt,omitempty"`
 
 	// Optional fields depending on the connection type
-	HttpMonitorHeaders []HttpMonitorHeader `gorm:"foreignKey:MonitorID" json:"headers"`
+	HttpMonitorHeaders    []HttpMonitorHeader    `gorm:"foreignKey:MonitorID" json:"headers"`
+	HttpMonitorAssertions []HttpMonitorAssertion `gorm:"foreignKey:MonitorID" json:"assertions"`
+
+	// Degraded is set while checks pass with a warning, e.g. a certificate
+	// close to expiry on a monitor that only warns about it
+	Degraded bool `json:"degraded"`
 
 	// Related database fields
-	Notification Notification   `gorm:"foreignKey:MonitorID" json:"notification,omitzero"`
-	Checks       []MonitorCheck `gorm:"foreignKey:MonitorID" json:"checks"`
+	Notifications []MonitorNotification `gorm:"foreignKey:MonitorID" json:"notifications"`
+	Checks        []MonitorCheck        `gorm:"foreignKey:MonitorID" json:"checks,omitempty"`
 }
 
 type MonitorCheck struct {
 	ID               uint      `gorm:"primaryKey;autoIncrement" json:"-"`
-	MonitorID        uint      `gorm:"index;not null" json:"-"`
-	Created          time.Time `json:"created"`
+	MonitorID        uint      `gorm:"index;index:idx_monitor_checks_range,priority:1;not null" json:"-"`
+	Created          time.Time `gorm:"index:idx_monitor_checks_range,priority:2" json:"created"`
 	Success          bool      `json:"success"`
 	Result           string    `json:"result"`
 	ResponseTimeMs   int64     `json:"responseTimeMs"`
 	NotificationSent bool      `json:"notificationSent"`
+	Maintenance      bool      `gorm:"default:false" json:"maintenance"`
 
 	Monitor Monitor `gorm:"foreignKey:MonitorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 }
 
+type RollupResolution string
+
+const (
+	RollupHourly RollupResolution = "hourly"
+	RollupDaily  RollupResolution = "daily"
+)
+
+// MonitorCheckRollup aggregates the checks of a monitor within one bucket once
+// the raw checks expire. Latencies only cover successful checks and checks
+// recorded during maintenance are left out.
+type MonitorCheckRollup struct {
+	ID          uint             `gorm:"primaryKey;autoIncrement" json:"-"`
+	MonitorID   uint             `gorm:"uniqueIndex:idx_rollup_bucket,priority:1;not null" json:"monitorId"`
+	Resolution  RollupResolution `gorm:"uniqueIndex:idx_rollup_bucket,priority:2" json:"resolution"`
+	BucketStart time.Time        `gorm:"uniqueIndex:idx_rollup_bucket,priority:3" json:"bucketStart"`
+	Count       int64            `json:"count"`
+	Successes   int64            `json:"successes"`
+	MinMs       int64            `json:"minMs"`
+	AvgMs       float64          `json:"avgMs"`
+	MaxMs       int64            `json:"maxMs"`
+	P95Ms       int64            `json:"p95Ms"`
+
+	Monitor Monitor `gorm:"foreignKey:MonitorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// Incident spans the time a monitor was confirmed down, ResolvedAt is nil
+// while the monitor is still down.
+type Incident struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	MonitorID  uint       `gorm:"index;not null" json:"monitorId"`
+	StartedAt  time.Time  `gorm:"index" json:"startedAt"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+	Cause      string     `json:"cause"`
+
+	AcknowledgedAt *time.Time `json:"acknowledgedAt,omitempty"`
+	AcknowledgedBy *uint      `json:"acknowledgedBy,omitempty"`
+
+	Monitor Monitor `gorm:"foreignKey:MonitorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// Notification is a channel notifications are sent to, shared by all
+// monitors linked to it. Default channels are linked to new monitors.
 type Notification struct {
 	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
-	MonitorID uint   `gorm:"uniqueIndex;not null" json:"monitorID"`
+	Name      string `gorm:"uniqueIndex;not null" json:"name"`
 	Enabled   bool   `json:"enabled"`
+	Default   bool   `json:"default"`
 	Type      string `json:"type"`
 	Webhook   string `json:"webhook"`
 	Email     string `json:"email"`
 	Template  `json:"template"`
+	TeamID    *uint     `gorm:"index" json:"teamId"` // nil if shared with everyone
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+
+	WebhookOptions WebhookOptions `gorm:"embedded;embeddedPrefix:webhook_" json:"webhookOptions"`
+}
+
+// WebhookOptions configures channels of the generic webhook type.
+type WebhookOptions struct {
+	Method  string            `json:"method"`
+	Headers map[string]string `gorm:"serializer:json" json:"headers"`
+	Secret  string            `json:"-"`
+	Body    string            `json:"body"`
+}
+
+// MarshalJSON leaves out the secret and the values of headers, which often
+// carry tokens. Responses only tell whether a secret is set.
+func (o WebhookOptions) MarshalJSON() ([]byte, error) {
+	type options WebhookOptions
+	redacted := options(o)
+	if o.Headers != nil {
+		redacted.Headers = make(map[string]string, len(o.Headers))
+		for key := range o.Headers {
+			redacted.Headers[key] = ""
+		}
+	}
+
+	return json.Marshal(struct {
+		options
+		HasSecret bool `json:"hasSecret"`
+	}{redacted, o.Secret != ""})
+}
+
+// MarshalJSON leaves out the webhook URL, which is a credential for most
+// platforms. Responses only include its host.
+func (n Notification) MarshalJSON() ([]byte, error) {
+	type channel Notification
+	redacted := channel(n)
+	redacted.Webhook = ""
+
+	var host string
+	if u, err := url.Parse(n.Webhook); err == nil {
+		host = u.Host
+	}
+
+	return json.Marshal(struct {
+		channel
+		WebhookHost string `json:"webhookHost"`
+	}{redacted, host})
+}
+
+func (Notification) TableName() string {
+	return "notification_channels"
+}
+
+type NotificationEvent string
+
+const (
+	NotifyDown     NotificationEvent = "down"
+	NotifyUp       NotificationEvent = "up"
+	NotifyReminder NotificationEvent = "reminder"
+	NotifyDegraded NotificationEvent = "degraded"
+)
+
+// DefaultNotificationEvents are sent over links created without a selection.
+var DefaultNotificationEvents = []NotificationEvent{NotifyDown, NotifyUp, NotifyReminder}
+
+// MonitorNotification links a monitor to a channel, Events selects which
+// events of the monitor are sent to it.
+type MonitorNotification struct {
+	MonitorID      uint                `gorm:"primaryKey" json:"-"`
+	NotificationID uint                `gorm:"primaryKey;index" json:"notificationId"`
+	Events         []NotificationEvent `gorm:"serializer:json" json:"events"`
+
+	Notification Notification `gorm:"foreignKey:NotificationID;constraint:OnDelete:CASCADE;" json:"-"`
 }
 
 type Template struct {
@@ -71,3 +282,152 @@ type HttpMonitorHeader struct {
 	Key       string `json:"key"`
 	Value     string `json:"value"`
 }
+
+type AssertionType string
+
+const (
+	AssertionStatusCode      AssertionType = "status_code"
+	AssertionBodyContains    AssertionType = "body_contains"
+	AssertionBodyNotContains AssertionType = "body_not_contains"
+	AssertionBodyRegex       AssertionType = "body_regex"
+	AssertionJSONPath        AssertionType = "json_path"
+	AssertionHeader          AssertionType = "header"
+	AssertionResponseTime    AssertionType = "response_time"
+)
+
+type AssertionOperator string
+
+const (
+	OperatorEqual          AssertionOperator = "eq"
+	OperatorNotEqual       AssertionOperator = "ne"
+	OperatorGreater        AssertionOperator = "gt"
+	OperatorGreaterOrEqual AssertionOperator = "gte"
+	OperatorLess           AssertionOperator = "lt"
+	OperatorLessOrEqual    AssertionOperator = "lte"
+	OperatorContains       AssertionOperator = "contains"
+	OperatorNotContains    AssertionOperator = "not_contains"
+	OperatorMatches        AssertionOperator = "matches"
+	OperatorExists         AssertionOperator = "exists"
+)
+
+// HttpMonitorAssertion is a single check run against an HTTP response.
+// Target holds the JSON path or header name, depending on the type.
+type HttpMonitorAssertion struct {
+	ID        uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	MonitorID uint              `gorm:"index;not null" json:"-"`
+	Type      AssertionType     `json:"type"`
+	Target    string            `json:"target"`
+	Operator  AssertionOperator `json:"operator"`
+	Value     string            `json:"value"`
+}
+
+type MaintenanceMode string
+
+const (
+	// MaintenanceSkip pauses checks during the window
+	MaintenanceSkip MaintenanceMode = "skip"
+	// MaintenanceRecord keeps checking but flags the checks as maintenance
+	MaintenanceRecord MaintenanceMode = "record"
+)
+
+type Recurrence string
+
+const (
+	RecurrenceNone    Recurrence = ""
+	RecurrenceDaily   Recurrence = "daily"
+	RecurrenceWeekly  Recurrence = "weekly"
+	RecurrenceMonthly Recurrence = "monthly"
+)
+
+// MaintenanceWindow suppresses notifications for the targeted monitors.
+// StartsAt and EndsAt describe the first occurrence, recurring windows repeat
+// it at the same wall clock time in Timezone until RepeatUntil.
+type MaintenanceWindow struct {
+	ID          uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Mode        MaintenanceMode `json:"mode"`
+	StartsAt    time.Time       `json:"startsAt"`
+	EndsAt      time.Time       `json:"endsAt"`
+	Recurrence  Recurrence      `json:"recurrence"`
+	RepeatUntil *time.Time      `json:"repeatUntil,omitempty"`
+	Timezone    string          `json:"timezone"`
+	MonitorIDs  []uint          `gorm:"serializer:json" json:"monitorIds"`
+	Tags        []string        `gorm:"serializer:json" json:"tags"`
+
+	// Teams that own the window, it only applies to monitors visible to them.
+	// Empty for windows of admins, which apply to all monitors.
+	TeamIDs []uint `gorm:"serializer:json" json:"teamIds"`
+}
+
+type Role string
+
+const (
+	// RoleViewer can only see monitors
+	RoleViewer Role = "viewer"
+	// RoleOperator can also run and pause monitors and acknowledge incidents
+	RoleOperator Role = "operator"
+	// RoleEditor can also change monitors, maintenance and notifications
+	RoleEditor Role = "editor"
+	// RoleAdmin can do everything, including managing users and teams, and
+	// sees the monitors of all teams
+	RoleAdmin Role = "admin"
+)
+
+type User struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Username     string    `gorm:"uniqueIndex;not null" json:"username"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	Role         Role      `gorm:"default:viewer" json:"role"`
+	Teams        []Team    `gorm:"many2many:team_members" json:"teams"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Team owns monitors, users only see shared monitors and those of their teams.
+type Team struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string    `gorm:"uniqueIndex;not null" json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Session is a dashboard login, only the SHA-256 hash of the cookie value is
+// stored.
+type Session struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"userId"`
+	TokenHash string    `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"index" json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+type TokenScope string
+
+const (
+	// ScopeRead allows all read only requests
+	ScopeRead TokenScope = "read"
+	// ScopeMonitorWrite allows reading and changing monitors and maintenance windows
+	ScopeMonitorWrite TokenScope = "monitors:write"
+	// ScopePush only allows reporting push monitor results
+	ScopePush TokenScope = "push"
+	// ScopeAdmin allows everything the owner of the token can do
+	ScopeAdmin TokenScope = "admin"
+)
+
+// APIToken authenticates scripts through an Authorization: Bearer header.
+// Only the SHA-256 hash of the token is stored, Prefix helps to recognize it.
+type APIToken struct {
+	ID         uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint         `gorm:"index;not null" json:"userId"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	TokenHash  string       `gorm:"uniqueIndex;not null" json:"-"`
+	Scopes     []TokenScope `gorm:"serializer:json" json:"scopes"`
+	ExpiresAt  *time.Time   `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time   `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time    `json:"createdAt"`
+
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"strings"
 	"sync"
 )
 
@@ -66,6 +67,21 @@ func (l *Logger) SetLevel(level LogLevel) {
 	}
 }
 
+func ParseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return DEBUG, nil
+	case "info":
+		return INFO, nil
+	case "warning", "warn":
+		return WARNING, nil
+	case "error":
+		return ERROR, nil
+	default:
+		return INFO, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
 func (l *Logger) SetJSON(json bool) {
 	l.JSON = json
 }
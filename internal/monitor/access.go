@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"fmt"
+	"honk/internal/database"
+	"slices"
+)
+
+// Access limits the monitors a caller can see and change. Shared monitors
+// without a team are visible to everyone.
+type Access struct {
+	All     bool
+	TeamIDs []uint
+}
+
+// FullAccess is used internally and when authentication is disabled.
+var FullAccess = Access{All: true}
+
+func (a Access) CanSee(mon *database.Monitor) bool {
+	return a.canSeeTeam(mon.TeamID)
+}
+
+// CanModify reports whether the monitor may be changed, paused or deleted.
+// Shared monitors are visible to every team but only full access changes them.
+func (a Access) CanModify(mon *database.Monitor) bool {
+	return a.canModifyTeam(mon.TeamID)
+}
+
+// CanSeeChannel reports whether the notification channel may be listed and
+// linked, channels follow the same rules as monitors.
+func (a Access) CanSeeChannel(channel *database.Notification) bool {
+	return a.canSeeTeam(channel.TeamID)
+}
+
+// CanModifyChannel reports whether the notification channel may be changed or
+// deleted, shared channels are managed by admins.
+func (a Access) CanModifyChannel(channel *database.Notification) bool {
+	return a.canModifyTeam(channel.TeamID)
+}
+
+func (a Access) canSeeTeam(teamID *uint) bool {
+	return a.All || teamID == nil || slices.Contains(a.TeamIDs, *teamID)
+}
+
+func (a Access) canModifyTeam(teamID *uint) bool {
+	return a.All || teamID != nil && slices.Contains(a.TeamIDs, *teamID)
+}
+
+// CanAssign reports whether a monitor or channel may be given to the team. Only
+// full access may share it with everyone by leaving the team nil.
+func (a Access) CanAssign(teamID *uint) bool {
+	if teamID == nil {
+		return a.All
+	}
+	return a.All || slices.Contains(a.TeamIDs, *teamID)
+}
+
+// DefaultTeam is the team of new monitors created without one, the only team
+// of the caller or nil when there is no single team.
+func (a Access) DefaultTeam() *uint {
+	if a.All || len(a.TeamIDs) != 1 {
+		return nil
+	}
+	teamID := a.TeamIDs[0]
+	return &teamID
+}
+
+// CanSeeMaintenance reports whether the maintenance window was created by one
+// of the teams of the caller, windows without teams belong to admins.
+func (a Access) CanSeeMaintenance(window *database.MaintenanceWindow) bool {
+	return a.All || slices.ContainsFunc(window.TeamIDs, func(id uint) bool {
+		return slices.Contains(a.TeamIDs, id)
+	})
+}
+
+// key identifies the access in caches of aggregated results.
+func (a Access) key() string {
+	if a.All {
+		return "all"
+	}
+	return fmt.Sprint(a.TeamIDs)
+}
@@ -0,0 +1,99 @@
+package monitor
+
+import (
+	"testing"
+
+	"honk/internal/database"
+)
+
+func TestAccess(t *testing.T) {
+	var (
+		teamA, teamB = uint(1), uint(2)
+		shared       = &database.Monitor{ID: 1}
+		ofA          = &database.Monitor{ID: 2, TeamID: &teamA}
+		ofB          = &database.Monitor{ID: 3, TeamID: &teamB}
+		memberOfA    = Access{TeamIDs: []uint{teamA}}
+		noTeams      = Access{}
+	)
+
+	tests := []struct {
+		name      string
+		access    Access
+		monitor   *database.Monitor
+		canSee    bool
+		canModify bool
+	}{
+		{"full access sees shared", FullAccess, shared, true, true},
+		{"full access sees any team", FullAccess, ofB, true, true},
+		{"member sees shared read only", memberOfA, shared, true, false},
+		{"member changes own team", memberOfA, ofA, true, true},
+		{"member misses other team", memberOfA, ofB, false, false},
+		{"no teams sees shared read only", noTeams, shared, true, false},
+		{"no teams misses team monitors", noTeams, ofA, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.access.CanSee(tt.monitor); got != tt.canSee {
+				t.Errorf("CanSee = %v, expected %v", got, tt.canSee)
+			}
+			if got := tt.access.CanModify(tt.monitor); got != tt.canModify {
+				t.Errorf("CanModify = %v, expected %v", got, tt.canModify)
+			}
+
+			// Channels of the same team follow the rules of monitors
+			channel := &database.Notification{TeamID: tt.monitor.TeamID}
+			if got := tt.access.CanSeeChannel(channel); got != tt.canSee {
+				t.Errorf("CanSeeChannel = %v, expected %v", got, tt.canSee)
+			}
+			if got := tt.access.CanModifyChannel(channel); got != tt.canModify {
+				t.Errorf("CanModifyChannel = %v, expected %v", got, tt.canModify)
+			}
+		})
+	}
+
+	if memberOfA.CanAssign(nil) || !FullAccess.CanAssign(nil) {
+		t.Error("only full access may share monitors")
+	}
+	if !memberOfA.CanAssign(&teamA) || memberOfA.CanAssign(&teamB) {
+		t.Error("members may only assign their own teams")
+	}
+	if got := memberOfA.DefaultTeam(); got == nil || *got != teamA {
+		t.Errorf("expected the only team as default, got %v", got)
+	}
+	if (Access{TeamIDs: []uint{teamA, teamB}}).DefaultTeam() != nil {
+		t.Error("expected no default team for members of several teams")
+	}
+}
+
+func TestTargetsMonitor(t *testing.T) {
+	var (
+		teamA, teamB = uint(1), uint(2)
+		shared       = &database.Monitor{ID: 1, Tags: []string{"db"}}
+		ofA          = &database.Monitor{ID: 2, TeamID: &teamA, Tags: []string{"db"}}
+		ofB          = &database.Monitor{ID: 3, TeamID: &teamB, Tags: []string{"db"}}
+	)
+
+	tests := []struct {
+		name    string
+		window  database.MaintenanceWindow
+		monitor *database.Monitor
+		want    bool
+	}{
+		{"admin window by tag", database.MaintenanceWindow{Tags: []string{"db"}}, shared, true},
+		{"admin window by id", database.MaintenanceWindow{MonitorIDs: []uint{3}}, ofB, true},
+		{"team window by tag", database.MaintenanceWindow{TeamIDs: []uint{teamA}, Tags: []string{"db"}}, ofA, true},
+		{"team window skips other team", database.MaintenanceWindow{TeamIDs: []uint{teamA}, Tags: []string{"db"}}, ofB, false},
+		{"team window skips shared", database.MaintenanceWindow{TeamIDs: []uint{teamA}, Tags: []string{"db"}}, shared, false},
+		{"team window skips shared by id", database.MaintenanceWindow{TeamIDs: []uint{teamA}, MonitorIDs: []uint{1}}, shared, false},
+		{"untargeted", database.MaintenanceWindow{Tags: []string{"web"}}, ofA, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := targetsMonitor(tt.window, tt.monitor); got != tt.want {
+				t.Errorf("targetsMonitor = %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"honk/internal/database"
+	"honk/internal/notification"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrChannelNotFound = errors.New("notification channel not found")
+	ErrInvalidChannel  = errors.New("invalid notification channel")
+)
+
+var notificationEvents = []database.NotificationEvent{
+	database.NotifyDown,
+	database.NotifyUp,
+	database.NotifyReminder,
+	database.NotifyDegraded,
+}
+
+// ListChannels returns the notification channels visible with the access.
+func (m *Manager) ListChannels(access Access) ([]database.Notification, error) {
+	channels := []database.Notification{}
+	if err := m.db.Order("id").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(channels, func(channel database.Notification) bool {
+		return !access.CanSeeChannel(&channel)
+	}), nil
+}
+
+// GetChannel loads a notification channel, channels of other teams are
+// reported as not found.
+func (m *Manager) GetChannel(id uint, access Access) (*database.Notification, error) {
+	var channel database.Notification
+	if err := m.db.Limit(1).Find(&channel, id).Error; err != nil {
+		return nil, err
+	}
+	if channel.ID == 0 || !access.CanSeeChannel(&channel) {
+		return nil, ErrChannelNotFound
+	}
+	return &channel, nil
+}
+
+func (m *Manager) CreateChannel(channel *database.Notification) error {
+	if err := m.validateChannel(channel); err != nil {
+		return err
+	}
+	if err := m.db.Create(channel).Error; err != nil {
+		return fmt.Errorf("failed to save notification channel: %w", err)
+	}
+
+	log.Info("notification channel added: %s", channel.Name)
+	return nil
+}
+
+func (m *Manager) UpdateChannel(channel *database.Notification) error {
+	if _, err := m.GetChannel(channel.ID, FullAccess); err != nil {
+		return err
+	}
+	if err := m.validateChannel(channel); err != nil {
+		return err
+	}
+
+	err := m.db.Model(channel).Select("*").Omit("created_at").Updates(channel).Error
+	if err != nil {
+		return fmt.Errorf("failed to update notification channel %d: %w", channel.ID, err)
+	}
+
+	log.Info("notification channel updated: %s (ID: %d)", channel.Name, channel.ID)
+	return nil
+}
+
+// DeleteChannel removes the channel and unlinks it from all monitors.
+func (m *Manager) DeleteChannel(id uint) error {
+	channel, err := m.GetChannel(id, FullAccess)
+	if err != nil {
+		return err
+	}
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("notification_id = ?", id).Delete(&database.MonitorNotification{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(channel).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel %d: %w", id, err)
+	}
+
+	m.mu.Lock()
+	for _, mon := range m.monitors {
+		mon.Notifications = slices.DeleteFunc(mon.Notifications, func(link database.MonitorNotification) bool {
+			return link.NotificationID == id
+		})
+	}
+	m.mu.Unlock()
+
+	log.Info("notification channel removed: %s (ID: %d)", channel.Name, id)
+	return nil
+}
+
+func (m *Manager) validateChannel(channel *database.Notification) error {
+	if channel.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidChannel)
+	}
+
+	if notification.Platform(channel.Type) == notification.Email {
+		if _, err := notification.ParseRecipients(channel.Email); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidChannel, err)
+		}
+	} else {
+		if _, err := notification.NewBuilder(notification.Platform(channel.Type)); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidChannel, err)
+		}
+		if channel.Webhook == "" {
+			return fmt.Errorf("%w: a webhook URL is required", ErrInvalidChannel)
+		}
+		if notification.Platform(channel.Type) == notification.Webhook {
+			if err := notification.WebhookOptions(channel.WebhookOptions).Validate(); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidChannel, err)
+			}
+		}
+	}
+
+	var count int64
+	err := m.db.Model(&database.Notification{}).Where("name = ? AND id <> ?", channel.Name, channel.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: a channel named %q already exists", ErrInvalidChannel, channel.Name)
+	}
+	return nil
+}
+
+// prepareLinks validates the links of a monitor and fills in the default
+// events. Nil links are replaced by the default channels the monitor may use.
+func (m *Manager) prepareLinks(mon *database.Monitor, links []database.MonitorNotification) ([]database.MonitorNotification, error) {
+	if links == nil {
+		var defaults []database.Notification
+		if err := m.db.Where(&database.Notification{Default: true}).Order("id").Find(&defaults).Error; err != nil {
+			return nil, fmt.Errorf("failed to load default notification channels: %w", err)
+		}
+
+		links = make([]database.MonitorNotification, 0, len(defaults))
+		for _, channel := range defaults {
+			if audience(&channel).CanSee(mon) {
+				links = append(links, database.MonitorNotification{NotificationID: channel.ID})
+			}
+		}
+	}
+
+	prepared := make([]database.MonitorNotification, 0, len(links))
+	for _, link := range links {
+		if slices.ContainsFunc(prepared, func(l database.MonitorNotification) bool { return l.NotificationID == link.NotificationID }) {
+			return nil, fmt.Errorf("%w: channel %d is linked twice", ErrInvalidMonitor, link.NotificationID)
+		}
+		channel, err := m.GetChannel(link.NotificationID, FullAccess)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w %d", ErrInvalidMonitor, err, link.NotificationID)
+		}
+		if !audience(channel).CanSee(mon) {
+			return nil, fmt.Errorf("%w: channel %d is not available to the team of the monitor", ErrInvalidMonitor, channel.ID)
+		}
+
+		if link.Events == nil {
+			link.Events = slices.Clone(database.DefaultNotificationEvents)
+		}
+		for _, event := range link.Events {
+			if !slices.Contains(notificationEvents, event) {
+				return nil, fmt.Errorf("%w: unknown notification event %q", ErrInvalidMonitor, event)
+			}
+		}
+
+		prepared = append(prepared, database.MonitorNotification{
+			NotificationID: link.NotificationID,
+			Events:         link.Events,
+		})
+	}
+	return prepared, nil
+}
+
+// audience is the access of the team receiving the messages of the channel.
+// Shared channels are set up by admins and may receive any monitor.
+func audience(channel *database.Notification) Access {
+	if channel.TeamID == nil {
+		return FullAccess
+	}
+	return Access{TeamIDs: []uint{*channel.TeamID}}
+}
+
+// saveLinks replaces the channels linked to the monitor.
+func saveLinks(tx *gorm.DB, monitorID uint, links []database.MonitorNotification) error {
+	if err := tx.Where("monitor_id = ?", monitorID).Delete(&database.MonitorNotification{}).Error; err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	for i := range links {
+		links[i].MonitorID = monitorID
+	}
+	return tx.Omit("Notification").Create(&links).Error
+}
@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"honk/internal/database"
+	"honk/internal/notification"
+)
+
+func TestPrepareLinksTeams(t *testing.T) {
+	m := newTestManager(t)
+
+	var (
+		teamA, teamB = uint(1), uint(2)
+		shared       = &database.Notification{Name: "shared", Type: "slack", Webhook: "https://hooks.example.com/shared", Default: true}
+		ofA          = &database.Notification{Name: "a", Type: "slack", Webhook: "https://hooks.example.com/a", Default: true, TeamID: &teamA}
+		ofB          = &database.Notification{Name: "b", Type: "slack", Webhook: "https://hooks.example.com/b", Default: true, TeamID: &teamB}
+	)
+	for _, channel := range []*database.Notification{shared, ofA, ofB} {
+		if err := m.CreateChannel(channel); err != nil {
+			t.Fatalf("failed to create channel %s: %v", channel.Name, err)
+		}
+	}
+
+	if channels, _ := m.ListChannels(Access{TeamIDs: []uint{teamA}}); len(channels) != 2 {
+		t.Errorf("expected the shared channel and the channel of team a, got %d channels", len(channels))
+	}
+	if _, err := m.GetChannel(ofB.ID, Access{TeamIDs: []uint{teamA}}); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("expected the channel of team b to be hidden, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		monitor  *database.Monitor
+		links    []uint
+		expected []uint
+		invalid  bool
+	}{
+		{"team monitor defaults", &database.Monitor{TeamID: &teamA}, nil, []uint{shared.ID, ofA.ID}, false},
+		{"shared monitor defaults", &database.Monitor{}, nil, []uint{shared.ID, ofA.ID, ofB.ID}, false},
+		{"own team channel", &database.Monitor{TeamID: &teamA}, []uint{ofA.ID}, []uint{ofA.ID}, false},
+		{"other team channel", &database.Monitor{TeamID: &teamA}, []uint{ofB.ID}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var links []database.MonitorNotification
+			for _, id := range tt.links {
+				links = append(links, database.MonitorNotification{NotificationID: id})
+			}
+
+			prepared, err := m.prepareLinks(tt.monitor, links)
+			if tt.invalid {
+				if !errors.Is(err, ErrInvalidMonitor) {
+					t.Errorf("expected an invalid monitor, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(prepared) != len(tt.expected) {
+				t.Fatalf("expected %d links, got %d", len(tt.expected), len(prepared))
+			}
+			for i, link := range prepared {
+				if link.NotificationID != tt.expected[i] {
+					t.Errorf("link %d: expected channel %d, got %d", i, tt.expected[i], link.NotificationID)
+				}
+			}
+		})
+	}
+}
+
+func TestDownMessageDependents(t *testing.T) {
+	m := newTestManager(t)
+
+	teamA, teamB := uint(1), uint(2)
+	m.monitors = map[int]*database.Monitor{
+		1: {ID: 1, Name: "database"},
+		2: {ID: 2, Name: "api", TeamID: &teamA, ParentIDs: []uint{1}},
+		3: {ID: 3, Name: "billing", TeamID: &teamB, ParentIDs: []uint{1}},
+		4: {ID: 4, Name: "invoices", TeamID: &teamA, ParentIDs: []uint{3}},
+		5: {ID: 5, Name: "reports", TeamID: &teamB, ParentIDs: []uint{3}},
+	}
+
+	tests := []struct {
+		name     string
+		channel  *database.Notification
+		expected string
+	}{
+		{"shared channel", &database.Notification{}, "api, billing, invoices, reports"},
+		{"team channel", &database.Notification{TeamID: &teamA}, "api, invoices, 2 monitors of other teams"},
+		{"other team channel", &database.Notification{TeamID: &teamB}, "billing, reports, 2 monitors of other teams"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := m.buildMessage(m.monitors[1], tt.channel, notification.EventDown, "connection refused", time.Now())
+			if expected := "Also affected (dependency down): " + tt.expected; !strings.HasSuffix(msg.Text, expected) {
+				t.Errorf("expected the text to end with %q, got %q", expected, msg.Text)
+			}
+		})
+	}
+}
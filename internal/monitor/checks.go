@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"fmt"
+	"honk/internal/database"
+	"time"
+)
+
+const (
+	DEFAULT_CHECKS_LIMIT = 100
+	MAX_CHECKS_LIMIT     = 1000
+)
+
+// CheckQuery filters the check history of a monitor. Checks are returned
+// newest first, Before is the cursor of the previous page.
+type CheckQuery struct {
+	Before        uint
+	Limit         int
+	From          time.Time
+	To            time.Time
+	Success       *bool
+	IncludeResult bool
+}
+
+type CheckPage struct {
+	Checks     []database.MonitorCheck `json:"checks"`
+	NextCursor uint                    `json:"nextCursor,omitempty"`
+}
+
+func (m *Manager) ListChecks(monitorID uint, q CheckQuery) (*CheckPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DEFAULT_CHECKS_LIMIT
+	}
+	limit = min(limit, MAX_CHECKS_LIMIT)
+
+	query := m.db.Where("monitor_id = ?", monitorID)
+	if !q.IncludeResult {
+		query = query.Omit("result")
+	}
+	if q.Before > 0 {
+		query = query.Where("id < ?", q.Before)
+	}
+	if !q.From.IsZero() {
+		query = query.Where("created >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		query = query.Where("created < ?", q.To)
+	}
+	if q.Success != nil {
+		query = query.Where("success = ?", *q.Success)
+	}
+
+	// Fetch one extra row to know whether another page exists
+	var checks []database.MonitorCheck
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&checks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load checks for monitor %d: %w", monitorID, err)
+	}
+
+	page := &CheckPage{Checks: checks}
+	if len(checks) > limit {
+		page.Checks = checks[:limit]
+		page.NextCursor = page.Checks[limit-1].ID
+	}
+
+	return page, nil
+}
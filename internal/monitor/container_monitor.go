@@ -4,15 +4,30 @@ import (
 	"context"
 	"fmt"
 	"honk/internal/database"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 )
 
 type ContainerHandler struct {
-	cli *client.Client
+	cli     *client.Client
+	Timeout time.Duration
+
+	mu       sync.Mutex
+	restarts map[uint]*restartHistory
+}
+
+// restartHistory remembers when restarts of a container were first observed,
+// as Docker only exposes the total restart count.
+type restartHistory struct {
+	lastCount int
+	observed  []time.Time
 }
 
-func NewContainerHandler() (*ContainerHandler, error) {
+func NewContainerHandler(ctx context.Context, timeout time.Duration) (*ContainerHandler, error) {
 	cli, err := client.NewClientWithOpts(
 		client.FromEnv,
 		client.WithAPIVersionNegotiation(),
@@ -21,18 +36,122 @@ func NewContainerHandler() (*ContainerHandler, error) {
 		return nil, err
 	}
 
-	return &ContainerHandler{cli: cli}, nil
+	if _, err := cli.Ping(ctx); err != nil {
+		_ = cli.Close()
+		return nil, fmt.Errorf("docker daemon not reachable: %w", err)
+	}
+
+	return &ContainerHandler{
+		cli:      cli,
+		Timeout:  timeout,
+		restarts: make(map[uint]*restartHistory),
+	}, nil
 }
 
-func (h *ContainerHandler) Check(ctx context.Context, m *database.Monitor) (string, error) {
-	inspect, err := h.cli.ContainerInspect(ctx, m.Connection)
+func (h *ContainerHandler) Check(ctx context.Context, m *database.Monitor) (string, int64, error) {
+	log.Debug("Container handler '%s' inspecting %s", m.Name, m.Connection)
+
+	timeout := h.Timeout
+	if m.Timeout > 0 {
+		timeout = time.Duration(m.Timeout) * time.Second
+	}
+
+	inspectCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	inspect, err := h.cli.ContainerInspect(inspectCtx, m.Connection)
+	duration := time.Since(start).Milliseconds()
 	if err != nil {
-		return "", err
+		return fmt.Sprintf("Failed to inspect container %s: %v", m.Connection, err), duration, err
+	}
+
+	state := inspect.State
+	if state == nil {
+		err := fmt.Errorf("container %s reported no state", m.Connection)
+		return err.Error(), duration, err
+	}
+
+	var (
+		health       = container.NoHealthcheck
+		restartCount = inspect.RestartCount
+		recent       = h.recentRestarts(m, restartCount, start)
+		report       strings.Builder
+	)
+
+	if state.Health != nil {
+		health = state.Health.Status
+	}
+
+	fmt.Fprintf(&report, "Status: %s\n", state.Status)
+	fmt.Fprintf(&report, "Health: %s\n", health)
+	fmt.Fprintf(&report, "Restarts: %d", restartCount)
+	if m.ContainerRestartWindow > 0 {
+		fmt.Fprintf(&report, " (%d in the last %s)", recent, time.Duration(m.ContainerRestartWindow)*time.Second)
+	}
+	fmt.Fprintf(&report, "\nOOM killed: %t\n", state.OOMKilled)
+	fmt.Fprintf(&report, "Exit code: %d", state.ExitCode)
+	if startedAt, err := time.Parse(time.RFC3339Nano, state.StartedAt); err == nil && state.Running {
+		fmt.Fprintf(&report, "\nUptime: %s", start.Sub(startedAt).Round(time.Second))
+	}
+	if state.Error != "" {
+		fmt.Fprintf(&report, "\nError: %s", state.Error)
+	}
+
+	var failure error
+	switch {
+	case !state.Running:
+		failure = fmt.Errorf("container %s is %s (exit code %d)", m.Connection, state.Status, state.ExitCode)
+	case state.Restarting:
+		failure = fmt.Errorf("container %s is restarting", m.Connection)
+	case m.ContainerFailOnUnhealthy && health == container.Unhealthy:
+		failure = fmt.Errorf("container %s is unhealthy (failing streak %d)", m.Connection, state.Health.FailingStreak)
+	case m.ContainerRestartWindow > 0 && recent > m.ContainerMaxRestarts:
+		failure = fmt.Errorf("container %s restarted %d times within %s", m.Connection, recent, time.Duration(m.ContainerRestartWindow)*time.Second)
+	}
+
+	if failure != nil {
+		if health == container.Unhealthy && len(state.Health.Log) > 0 {
+			last := state.Health.Log[len(state.Health.Log)-1]
+			fmt.Fprintf(&report, "\nLast health check output: %s", strings.TrimSpace(last.Output))
+		}
+		return fmt.Sprintf("%v\n\n%s", failure, report.String()), duration, failure
+	}
+
+	return report.String(), duration, nil
+}
+
+// Forget drops the restart history of a changed or removed monitor.
+func (h *ContainerHandler) Forget(id uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.restarts, id)
+}
+
+// recentRestarts records newly observed restarts of the monitored container
+// and returns how many happened within the configured window.
+func (h *ContainerHandler) recentRestarts(m *database.Monitor, count int, now time.Time) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	history, ok := h.restarts[m.ID]
+	if !ok || count < history.lastCount {
+		// First check or the container was recreated, start counting afresh
+		h.restarts[m.ID] = &restartHistory{lastCount: count}
+		return 0
+	}
+
+	for range count - history.lastCount {
+		history.observed = append(history.observed, now)
 	}
+	history.lastCount = count
 
-	if !inspect.State.Running {
-		return "", fmt.Errorf("container %s not running", m.Connection)
+	window := time.Duration(m.ContainerRestartWindow) * time.Second
+	cutoff := now.Add(-window)
+	for len(history.observed) > 0 && history.observed[0].Before(cutoff) {
+		history.observed = history.observed[1:]
 	}
 
-	return "", nil
+	return len(history.observed)
 }
@@ -0,0 +1,175 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"honk/internal/database"
+	"slices"
+)
+
+var ErrInvalidDependency = errors.New("invalid dependency")
+
+type DependencyNode struct {
+	ID     uint                   `json:"id"`
+	Name   string                 `json:"name"`
+	Status database.MonitorStatus `json:"status"`
+}
+
+type DependencyEdge struct {
+	Parent uint `json:"parent"`
+	Child  uint `json:"child"`
+}
+
+type DependencyGraph struct {
+	Nodes []DependencyNode `json:"nodes"`
+	Edges []DependencyEdge `json:"edges"`
+}
+
+func (m *Manager) DependencyGraph(access Access) DependencyGraph {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	graph := DependencyGraph{
+		Nodes: []DependencyNode{},
+		Edges: []DependencyEdge{},
+	}
+
+	for _, mon := range m.monitors {
+		if !access.CanSee(mon) {
+			continue
+		}
+
+		graph.Nodes = append(graph.Nodes, DependencyNode{ID: mon.ID, Name: mon.Name, Status: mon.Status})
+		for _, parent := range mon.ParentIDs {
+			if p, ok := m.monitors[int(parent)]; !ok || !access.CanSee(p) {
+				continue
+			}
+			graph.Edges = append(graph.Edges, DependencyEdge{Parent: parent, Child: mon.ID})
+		}
+	}
+
+	slices.SortFunc(graph.Nodes, func(a, b DependencyNode) int { return int(a.ID) - int(b.ID) })
+	return graph
+}
+
+// validateParents checks that all parents exist and that depending on them
+// does not introduce a cycle. Callers must hold m.mu.
+func (m *Manager) validateParents(id uint, parents []uint) error {
+	for _, parent := range parents {
+		if parent == id {
+			return fmt.Errorf("%w: monitor cannot depend on itself", ErrInvalidDependency)
+		}
+		if _, ok := m.monitors[int(parent)]; !ok {
+			return fmt.Errorf("%w: parent monitor %d does not exist", ErrInvalidDependency, parent)
+		}
+	}
+
+	// New monitors have no dependents yet, so they cannot close a cycle
+	if id == 0 {
+		return nil
+	}
+
+	visited := make(map[uint]bool)
+	queue := slices.Clone(parents)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current == id {
+			return fmt.Errorf("%w: dependency cycle through monitor %d", ErrInvalidDependency, current)
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		if mon, ok := m.monitors[int(current)]; ok {
+			queue = append(queue, mon.ParentIDs...)
+		}
+	}
+
+	return nil
+}
+
+// downDependency returns the root cause when one of the monitor's parents is
+// down, following unreachable parents up to the monitor that is actually down.
+func (m *Manager) downDependency(mon *database.Monitor) *database.Monitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	visited := make(map[uint]bool)
+	queue := slices.Clone(mon.ParentIDs)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		parent, ok := m.monitors[int(current)]
+		if !ok {
+			continue
+		}
+
+		switch parent.Status {
+		case database.StatusDown:
+			return parent
+		case database.StatusUnreachable:
+			queue = append(queue, parent.ParentIDs...)
+		}
+	}
+
+	return nil
+}
+
+// dependentNames returns the names of all monitors depending directly or
+// indirectly on the given monitor that are visible with the access, the others
+// are only counted.
+func (m *Manager) dependentNames(id uint, access Access) (names []string, hidden int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		visited = map[uint]bool{id: true}
+		queue   = []uint{id}
+	)
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, mon := range m.monitors {
+			if visited[mon.ID] || !slices.Contains(mon.ParentIDs, current) {
+				continue
+			}
+			visited[mon.ID] = true
+			queue = append(queue, mon.ID)
+
+			if access.CanSee(mon) {
+				names = append(names, mon.Name)
+			} else {
+				hidden++
+			}
+		}
+	}
+
+	slices.Sort(names)
+	return names, hidden
+}
+
+// removeParent drops a deleted monitor from the parents of its dependents.
+// Callers must hold m.mu.
+func (m *Manager) removeParent(id uint) {
+	for _, mon := range m.monitors {
+		if !slices.Contains(mon.ParentIDs, id) {
+			continue
+		}
+
+		mon.ParentIDs = slices.DeleteFunc(mon.ParentIDs, func(parent uint) bool { return parent == id })
+		if err := m.db.Model(mon).Update("parent_ids", mon.ParentIDs).Error; err != nil {
+			log.Error("failed to remove parent %d from monitor %d: %v", id, mon.ID, err)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"honk/internal/database"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	DEFAULT_DNS_RESOLVER = "127.0.0.1:53"
+
+	maxDNSMessageSize = 4096
+)
+
+var dnsRecordTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT,
+	"NS":    dnsmessage.TypeNS,
+	"SRV":   dnsmessage.TypeSRV,
+}
+
+type dnsAnswer struct {
+	Value string
+	TTL   uint32
+}
+
+type DNSHandler struct {
+	Timeout         time.Duration
+	DefaultResolver string
+}
+
+func NewDNSHandler(timeout time.Duration) *DNSHandler {
+	return &DNSHandler{
+		Timeout:         timeout,
+		DefaultResolver: systemResolver(),
+	}
+}
+
+func (h *DNSHandler) Check(ctx context.Context, m *database.Monitor) (string, int64, error) {
+	recordType := strings.ToUpper(m.DNSRecordType)
+	if recordType == "" {
+		recordType = "A"
+	}
+
+	qtype, ok := dnsRecordTypes[recordType]
+	if !ok {
+		err := fmt.Errorf("unsupported record type %q", m.DNSRecordType)
+		return err.Error(), 0, err
+	}
+
+	resolver := h.DefaultResolver
+	if m.DNSResolver != "" {
+		resolver = m.DNSResolver
+	}
+	if _, _, err := net.SplitHostPort(resolver); err != nil {
+		resolver = net.JoinHostPort(resolver, "53")
+	}
+
+	timeout := h.Timeout
+	if m.Timeout > 0 {
+		timeout = time.Duration(m.Timeout) * time.Second
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	log.Debug("DNS handler '%s' resolving %s %s via %s", m.Name, recordType, m.Connection, resolver)
+
+	start := time.Now()
+	answers, err := queryDNS(queryCtx, resolver, m.Connection, qtype)
+	duration := time.Since(start).Milliseconds()
+	if err != nil {
+		return fmt.Sprintf("DNS query for %s %s via %s failed after %dms: %v", recordType, m.Connection, resolver, duration, err), duration, err
+	}
+
+	result := fmt.Sprintf("Resolved %s %s via %s in %dms", recordType, m.Connection, resolver, duration)
+	for _, answer := range answers {
+		result += fmt.Sprintf("\n%s (TTL %d)", answer.Value, answer.TTL)
+	}
+
+	if failures := checkDNSAnswers(m, answers); len(failures) > 0 {
+		for _, failure := range failures {
+			result += "\n- " + failure
+		}
+		return result, duration, fmt.Errorf("%d DNS assertion(s) failed", len(failures))
+	}
+
+	return result, duration, nil
+}
+
+// checkDNSAnswers compares the answer set against the monitor's expectations
+// and returns a message for every expectation that did not hold.
+func checkDNSAnswers(m *database.Monitor, answers []dnsAnswer) []string {
+	var (
+		failures []string
+		values   = make([]string, 0, len(answers))
+		expected []string
+	)
+
+	for _, answer := range answers {
+		values = append(values, normalizeDNSValue(answer.Value))
+	}
+	for value := range strings.SplitSeq(m.DNSExpected, ",") {
+		if value = normalizeDNSValue(value); value != "" {
+			expected = append(expected, value)
+		}
+	}
+
+	switch m.DNSMatch {
+	case database.DNSMatchNonEmpty, "":
+		if len(answers) == 0 {
+			failures = append(failures, "answer set is empty")
+		}
+	case database.DNSMatchExact:
+		slices.Sort(values)
+		slices.Sort(expected)
+		if !slices.Equal(slices.Compact(values), slices.Compact(expected)) {
+			failures = append(failures, fmt.Sprintf("answer set [%s] does not match [%s]", strings.Join(values, ", "), strings.Join(expected, ", ")))
+		}
+	case database.DNSMatchContains:
+		for _, value := range expected {
+			if !slices.Contains(values, value) {
+				failures = append(failures, fmt.Sprintf("answer set does not contain %s", value))
+			}
+		}
+	default:
+		failures = append(failures, fmt.Sprintf("unknown match mode %q", m.DNSMatch))
+	}
+
+	for _, answer := range answers {
+		if m.DNSMinTTL > 0 && answer.TTL < uint32(m.DNSMinTTL) {
+			failures = append(failures, fmt.Sprintf("TTL %d of %s is below %d", answer.TTL, answer.Value, m.DNSMinTTL))
+		}
+		if m.DNSMaxTTL > 0 && answer.TTL > uint32(m.DNSMaxTTL) {
+			failures = append(failures, fmt.Sprintf("TTL %d of %s is above %d", answer.TTL, answer.Value, m.DNSMaxTTL))
+		}
+	}
+
+	return failures
+}
+
+func normalizeDNSValue(value string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(value), "."))
+}
+
+// queryDNS sends a single question to the resolver over UDP, retrying over
+// TCP when the response is truncated.
+func queryDNS(ctx context.Context, resolver, name string, qtype dnsmessage.Type) ([]dnsAnswer, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name %q: %w", name, err)
+	}
+
+	id := uint16(rand.Uint32())
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	response, err := exchangeDNS(ctx, "udp", resolver, packed)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(response); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+
+	if msg.Truncated {
+		if response, err = exchangeDNS(ctx, "tcp", resolver, packed); err != nil {
+			return nil, err
+		}
+		if err := msg.Unpack(response); err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+	}
+
+	if msg.ID != id {
+		return nil, fmt.Errorf("response id %d does not match query id %d", msg.ID, id)
+	}
+	if msg.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("resolver answered %s", msg.RCode)
+	}
+
+	var answers []dnsAnswer
+	for _, rr := range msg.Answers {
+		if rr.Header.Type != qtype {
+			continue
+		}
+		answers = append(answers, dnsAnswer{Value: dnsRecordValue(rr.Body), TTL: rr.Header.TTL})
+	}
+
+	return answers, nil
+}
+
+func exchangeDNS(ctx context.Context, network, resolver string, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, resolver)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Warning("failed to close dns connection: %v", closeErr)
+		}
+	}()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, maxDNSMessageSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	// DNS over TCP prefixes every message with its length
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(framed, query...)); err != nil {
+		return nil, err
+	}
+
+	var length uint16
+	if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func dnsRecordValue(body dnsmessage.ResourceBody) string {
+	switch rr := body.(type) {
+	case *dnsmessage.AResource:
+		return netip.AddrFrom4(rr.A).String()
+	case *dnsmessage.AAAAResource:
+		return netip.AddrFrom16(rr.AAAA).String()
+	case *dnsmessage.CNAMEResource:
+		return rr.CNAME.String()
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", rr.Pref, rr.MX.String())
+	case *dnsmessage.TXTResource:
+		return strings.Join(rr.TXT, "")
+	case *dnsmessage.NSResource:
+		return rr.NS.String()
+	case *dnsmessage.SRVResource:
+		return fmt.Sprintf("%d %d %d %s", rr.Priority, rr.Weight, rr.Port, rr.Target.String())
+	default:
+		return body.GoString()
+	}
+}
+
+// systemResolver returns the first nameserver listed in /etc/resolv.conf.
+func systemResolver() string {
+	file, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return DEFAULT_DNS_RESOLVER
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], "53")
+		}
+	}
+
+	return DEFAULT_DNS_RESOLVER
+}
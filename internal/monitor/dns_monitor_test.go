@@ -0,0 +1,301 @@
+package monitor
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"honk/internal/database"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testResolver answers DNS questions from a fixed set of records over UDP and
+// TCP on the same port. Names listed in truncate only get their answers over
+// TCP, UDP responses for them have the truncated bit set.
+type testResolver struct {
+	addr     string
+	records  map[string][]dnsmessage.Resource
+	truncate map[string]bool
+
+	tcpQueries atomic.Int32
+}
+
+func newTestResolver(t *testing.T, records map[string][]dnsmessage.Resource, truncate map[string]bool) *testResolver {
+	t.Helper()
+
+	udp, tcp := listenDNS(t)
+	r := &testResolver{
+		addr:     udp.LocalAddr().String(),
+		records:  records,
+		truncate: truncate,
+	}
+	t.Cleanup(func() {
+		udp.Close()
+		tcp.Close()
+	})
+
+	go r.serveUDP(udp)
+	go r.serveTCP(tcp)
+	return r
+}
+
+// listenDNS opens a UDP and a TCP listener on the same free port.
+func listenDNS(t *testing.T) (net.PacketConn, net.Listener) {
+	t.Helper()
+
+	for range 10 {
+		udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen on udp: %v", err)
+		}
+
+		tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+		if err == nil {
+			return udp, tcp
+		}
+		udp.Close()
+	}
+
+	t.Fatal("no port free for both udp and tcp")
+	return nil, nil
+}
+
+func (r *testResolver) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, maxDNSMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		if response, err := r.answer(buf[:n], true); err == nil {
+			_, _ = conn.WriteTo(response, addr)
+		}
+	}
+}
+
+func (r *testResolver) serveTCP(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		r.tcpQueries.Add(1)
+
+		go func() {
+			defer conn.Close()
+
+			var length uint16
+			if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+				return
+			}
+			query := make([]byte, length)
+			if _, err := io.ReadFull(conn, query); err != nil {
+				return
+			}
+
+			response, err := r.answer(query, false)
+			if err != nil {
+				return
+			}
+			framed := binary.BigEndian.AppendUint16(nil, uint16(len(response)))
+			_, _ = conn.Write(append(framed, response...))
+		}()
+	}
+}
+
+func (r *testResolver) answer(query []byte, udp bool) ([]byte, error) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil {
+		return nil, err
+	}
+	if len(msg.Questions) != 1 {
+		return nil, errors.New("expected a single question")
+	}
+
+	question := msg.Questions[0]
+	name := question.Name.String()
+
+	response := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 msg.ID,
+			Response:           true,
+			RecursionDesired:   msg.RecursionDesired,
+			RecursionAvailable: true,
+		},
+		Questions: msg.Questions,
+	}
+
+	records, ok := r.records[name]
+	switch {
+	case !ok:
+		response.RCode = dnsmessage.RCodeNameError
+	case udp && r.truncate[name]:
+		response.Truncated = true
+	default:
+		// Like real resolvers, the CNAME chain is answered for any type
+		for _, rr := range records {
+			if rr.Header.Type == question.Type || rr.Header.Type == dnsmessage.TypeCNAME {
+				response.Answers = append(response.Answers, rr)
+			}
+		}
+	}
+
+	return response.Pack()
+}
+
+func dnsRecord(name string, ttl uint32, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	var rtype dnsmessage.Type
+	switch body.(type) {
+	case *dnsmessage.AResource:
+		rtype = dnsmessage.TypeA
+	case *dnsmessage.CNAMEResource:
+		rtype = dnsmessage.TypeCNAME
+	case *dnsmessage.TXTResource:
+		rtype = dnsmessage.TypeTXT
+	}
+
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  rtype,
+			Class: dnsmessage.ClassINET,
+			TTL:   ttl,
+		},
+		Body: body,
+	}
+}
+
+func TestDNSHandlerCheck(t *testing.T) {
+	records := map[string][]dnsmessage.Resource{
+		"example.test.": {
+			dnsRecord("example.test.", 300, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}}),
+			dnsRecord("example.test.", 300, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 2}}),
+			dnsRecord("example.test.", 60, &dnsmessage.TXTResource{TXT: []string{"v=spf1 ", "-all"}}),
+		},
+		"www.example.test.": {
+			dnsRecord("www.example.test.", 120, &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("example.test.")}),
+		},
+	}
+	resolver := newTestResolver(t, records, nil)
+
+	tests := []struct {
+		name    string
+		monitor database.Monitor
+		result  string
+		wantErr string
+	}{
+		{
+			name:    "a records",
+			monitor: database.Monitor{Connection: "example.test", DNSMatch: database.DNSMatchExact, DNSExpected: "192.0.2.2, 192.0.2.1"},
+			result:  "192.0.2.1 (TTL 300)\n192.0.2.2 (TTL 300)",
+		},
+		{
+			name:    "a record missing",
+			monitor: database.Monitor{Connection: "example.test", DNSMatch: database.DNSMatchContains, DNSExpected: "192.0.2.3"},
+			result:  "answer set does not contain 192.0.2.3",
+			wantErr: "1 DNS assertion(s) failed",
+		},
+		{
+			name:    "cname",
+			monitor: database.Monitor{Connection: "www.example.test", DNSRecordType: "CNAME", DNSMatch: database.DNSMatchExact, DNSExpected: "example.test"},
+			result:  "example.test. (TTL 120)",
+		},
+		{
+			name:    "txt joins strings",
+			monitor: database.Monitor{Connection: "example.test", DNSRecordType: "txt", DNSMatch: database.DNSMatchExact, DNSExpected: "v=spf1 -all"},
+			result:  "v=spf1 -all (TTL 60)",
+		},
+		{
+			name:    "ttl bounds",
+			monitor: database.Monitor{Connection: "example.test", DNSRecordType: "TXT", DNSMinTTL: 120},
+			result:  "TTL 60 of v=spf1 -all is below 120",
+			wantErr: "1 DNS assertion(s) failed",
+		},
+		{
+			name:    "nxdomain",
+			monitor: database.Monitor{Connection: "missing.example.test"},
+			wantErr: "resolver answered RCodeNameError",
+		},
+		{
+			name:    "empty answer",
+			monitor: database.Monitor{Connection: "www.example.test", DNSRecordType: "MX"},
+			result:  "answer set is empty",
+			wantErr: "1 DNS assertion(s) failed",
+		},
+		{
+			name:    "unsupported type",
+			monitor: database.Monitor{Connection: "example.test", DNSRecordType: "PTR"},
+			wantErr: `unsupported record type "PTR"`,
+		},
+	}
+
+	handler := &DNSHandler{Timeout: 2 * time.Second, DefaultResolver: resolver.addr}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, err := handler.Check(context.Background(), &tt.monitor)
+
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v\n%s", err, result)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if !strings.Contains(result, tt.result) {
+				t.Errorf("result does not contain %q:\n%s", tt.result, result)
+			}
+		})
+	}
+}
+
+func TestDNSHandlerTruncatedFallsBackToTCP(t *testing.T) {
+	records := map[string][]dnsmessage.Resource{
+		"big.example.test.": {
+			dnsRecord("big.example.test.", 300, &dnsmessage.AResource{A: [4]byte{198, 51, 100, 7}}),
+		},
+	}
+	resolver := newTestResolver(t, records, map[string]bool{"big.example.test.": true})
+
+	handler := &DNSHandler{Timeout: 2 * time.Second}
+	mon := &database.Monitor{
+		Connection:  "big.example.test",
+		DNSResolver: resolver.addr,
+		DNSMatch:    database.DNSMatchExact,
+		DNSExpected: "198.51.100.7",
+	}
+
+	result, _, err := handler.Check(context.Background(), mon)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, result)
+	}
+	if !strings.Contains(result, "198.51.100.7 (TTL 300)") {
+		t.Errorf("answer from tcp missing:\n%s", result)
+	}
+	if got := resolver.tcpQueries.Load(); got != 1 {
+		t.Errorf("expected 1 tcp query, got %d", got)
+	}
+}
+
+func TestDNSHandlerTimeout(t *testing.T) {
+	// A resolver that never answers
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on udp: %v", err)
+	}
+	defer conn.Close()
+
+	handler := &DNSHandler{Timeout: 100 * time.Millisecond, DefaultResolver: conn.LocalAddr().String()}
+	_, _, err = handler.Check(context.Background(), &database.Monitor{Connection: "example.test"})
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"honk/internal/database"
+	"time"
+)
+
+// Subscribers that do not keep up lose events instead of blocking checks.
+const eventBufferSize = 64
+
+type EventType string
+
+const (
+	EventCheck          EventType = "check"
+	EventStateChange    EventType = "state_change"
+	EventMonitorCreated EventType = "monitor_created"
+	EventMonitorUpdated EventType = "monitor_updated"
+	EventMonitorDeleted EventType = "monitor_deleted"
+)
+
+// Event describes a change of a monitor. Monitor is a snapshot taken when the
+// event was published, From and To are only set for state changes.
+type Event struct {
+	Type      EventType              `json:"type"`
+	MonitorID uint                   `json:"monitorId"`
+	Timestamp time.Time              `json:"timestamp"`
+	Monitor   *database.Monitor      `json:"monitor,omitempty"`
+	Check     *database.MonitorCheck `json:"check,omitempty"`
+	From      database.MonitorStatus `json:"from,omitempty"`
+	To        database.MonitorStatus `json:"to,omitempty"`
+}
+
+// Subscribe returns a channel receiving all monitor events and a function to
+// stop the subscription, which closes the channel.
+func (m *Manager) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+
+	m.subscribersMu.Lock()
+	id := m.nextSubscriber
+	m.nextSubscriber++
+	m.subscribers[id] = ch
+	m.subscribersMu.Unlock()
+
+	unsubscribe := func() {
+		m.subscribersMu.Lock()
+		defer m.subscribersMu.Unlock()
+
+		if _, ok := m.subscribers[id]; ok {
+			delete(m.subscribers, id)
+			close(ch)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (m *Manager) publish(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	m.subscribersMu.Lock()
+	defer m.subscribersMu.Unlock()
+
+	for id, ch := range m.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Debug("dropping %s event for slow subscriber %d", event.Type, id)
+		}
+	}
+}
+
+func (m *Manager) publishMonitor(eventType EventType, mon *database.Monitor) {
+	m.publish(Event{
+		Type:      eventType,
+		MonitorID: mon.ID,
+		Monitor:   snapshotMonitor(mon),
+	})
+}
+
+func (m *Manager) publishCheck(mon *database.Monitor, check *database.MonitorCheck) {
+	m.publish(Event{
+		Type:      EventCheck,
+		MonitorID: mon.ID,
+		Timestamp: check.Created,
+		Monitor:   snapshotMonitor(mon),
+		Check:     check,
+	})
+}
+
+func (m *Manager) publishStateChange(mon *database.Monitor, from database.MonitorStatus, at time.Time) {
+	m.publish(Event{
+		Type:      EventStateChange,
+		MonitorID: mon.ID,
+		Timestamp: at,
+		Monitor:   snapshotMonitor(mon),
+		From:      from,
+		To:        mon.Status,
+	})
+}
+
+// snapshotMonitor copies the monitor for an event, so later changes by the
+// runner do not race with subscribers encoding it.
+func snapshotMonitor(mon *database.Monitor) *database.Monitor {
+	snapshot := *mon
+	snapshot.Checks = nil
+	return &snapshot
+}
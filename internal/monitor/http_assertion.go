@@ -0,0 +1,324 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"honk/internal/database"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// httpResponse is the part of a response that assertions are evaluated against.
+type httpResponse struct {
+	StatusCode     int
+	Header         http.Header
+	Body           []byte
+	ResponseTimeMs int64
+}
+
+// hasStatusAssertion reports whether the default "status >= 400 fails" rule is
+// replaced by user-defined status code ranges.
+func hasStatusAssertion(assertions []database.HttpMonitorAssertion) bool {
+	for _, a := range assertions {
+		if a.Type == database.AssertionStatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func needsBody(assertions []database.HttpMonitorAssertion) bool {
+	for _, a := range assertions {
+		switch a.Type {
+		case database.AssertionBodyContains, database.AssertionBodyNotContains,
+			database.AssertionBodyRegex, database.AssertionJSONPath:
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateAssertions returns one message for every assertion that did not hold.
+func evaluateAssertions(assertions []database.HttpMonitorAssertion, resp httpResponse) []string {
+	var failures []string
+
+	for _, a := range assertions {
+		if err := evaluateAssertion(a, resp); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	return failures
+}
+
+func evaluateAssertion(a database.HttpMonitorAssertion, resp httpResponse) error {
+	switch a.Type {
+	case database.AssertionStatusCode:
+		ok, err := statusInRanges(resp.StatusCode, a.Value)
+		if err != nil {
+			return fmt.Errorf("status code: %v", err)
+		}
+		if !ok {
+			return fmt.Errorf("status code %d is not in %s", resp.StatusCode, a.Value)
+		}
+
+	case database.AssertionBodyContains:
+		if !bytes.Contains(resp.Body, []byte(a.Value)) {
+			return fmt.Errorf("body does not contain %q", a.Value)
+		}
+
+	case database.AssertionBodyNotContains:
+		if bytes.Contains(resp.Body, []byte(a.Value)) {
+			return fmt.Errorf("body contains %q", a.Value)
+		}
+
+	case database.AssertionBodyRegex:
+		re, err := regexp.Compile(a.Value)
+		if err != nil {
+			return fmt.Errorf("body regex %q is invalid: %v", a.Value, err)
+		}
+		if !re.Match(resp.Body) {
+			return fmt.Errorf("body does not match %q", a.Value)
+		}
+
+	case database.AssertionJSONPath:
+		var doc any
+		if err := json.Unmarshal(resp.Body, &doc); err != nil {
+			return fmt.Errorf("json path %s: body is not valid JSON", a.Target)
+		}
+
+		value, found, err := lookupJSONPath(doc, a.Target)
+		if err != nil {
+			return fmt.Errorf("json path %s: %v", a.Target, err)
+		}
+		if !found {
+			return fmt.Errorf("json path %s does not exist", a.Target)
+		}
+		if a.Operator == database.OperatorExists {
+			return nil
+		}
+
+		if err := compare(jsonValueString(value), a.Operator, a.Value); err != nil {
+			return fmt.Errorf("json path %s: %v", a.Target, err)
+		}
+
+	case database.AssertionHeader:
+		values, found := resp.Header[http.CanonicalHeaderKey(a.Target)]
+		if !found {
+			return fmt.Errorf("header %s is missing", a.Target)
+		}
+		if a.Operator == database.OperatorExists {
+			return nil
+		}
+
+		if err := compare(strings.Join(values, ", "), a.Operator, a.Value); err != nil {
+			return fmt.Errorf("header %s: %v", a.Target, err)
+		}
+
+	case database.AssertionResponseTime:
+		maxMs, err := strconv.ParseInt(a.Value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("response time limit %q is not a number", a.Value)
+		}
+		if resp.ResponseTimeMs > maxMs {
+			return fmt.Errorf("response time %dms exceeds %dms", resp.ResponseTimeMs, maxMs)
+		}
+
+	default:
+		return fmt.Errorf("unknown assertion type %q", a.Type)
+	}
+
+	return nil
+}
+
+// statusInRanges checks a status code against a list such as "200-299,301".
+func statusInRanges(code int, ranges string) (bool, error) {
+	for part := range strings.SplitSeq(ranges, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		lowStr, highStr, isRange := strings.Cut(part, "-")
+		low, err := strconv.Atoi(strings.TrimSpace(lowStr))
+		if err != nil {
+			return false, fmt.Errorf("invalid status code %q", part)
+		}
+
+		high := low
+		if isRange {
+			high, err = strconv.Atoi(strings.TrimSpace(highStr))
+			if err != nil {
+				return false, fmt.Errorf("invalid status code range %q", part)
+			}
+		}
+
+		if code >= low && code <= high {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// compare applies op to actual and expected. Ordering operators compare
+// numerically and fail when either side is not a number.
+func compare(actual string, op database.AssertionOperator, expected string) error {
+	switch op {
+	case database.OperatorEqual, "":
+		if !valuesEqual(actual, expected) {
+			return fmt.Errorf("expected %q, got %q", expected, actual)
+		}
+	case database.OperatorNotEqual:
+		if valuesEqual(actual, expected) {
+			return fmt.Errorf("expected value other than %q", expected)
+		}
+	case database.OperatorContains:
+		if !strings.Contains(actual, expected) {
+			return fmt.Errorf("%q does not contain %q", actual, expected)
+		}
+	case database.OperatorNotContains:
+		if strings.Contains(actual, expected) {
+			return fmt.Errorf("%q contains %q", actual, expected)
+		}
+	case database.OperatorMatches:
+		re, err := regexp.Compile(expected)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %v", expected, err)
+		}
+		if !re.MatchString(actual) {
+			return fmt.Errorf("%q does not match %q", actual, expected)
+		}
+	case database.OperatorGreater, database.OperatorGreaterOrEqual,
+		database.OperatorLess, database.OperatorLessOrEqual:
+		a, errA := strconv.ParseFloat(actual, 64)
+		e, errE := strconv.ParseFloat(expected, 64)
+		if errA != nil || errE != nil {
+			return fmt.Errorf("cannot compare %q %s %q numerically", actual, op, expected)
+		}
+
+		var ok bool
+		switch op {
+		case database.OperatorGreater:
+			ok = a > e
+		case database.OperatorGreaterOrEqual:
+			ok = a >= e
+		case database.OperatorLess:
+			ok = a < e
+		case database.OperatorLessOrEqual:
+			ok = a <= e
+		}
+		if !ok {
+			return fmt.Errorf("expected %s %s %s", actual, op, expected)
+		}
+	default:
+		return fmt.Errorf("unknown operator %q", op)
+	}
+
+	return nil
+}
+
+func valuesEqual(actual, expected string) bool {
+	if actual == expected {
+		return true
+	}
+
+	a, errA := strconv.ParseFloat(actual, 64)
+	e, errE := strconv.ParseFloat(expected, 64)
+	return errA == nil && errE == nil && a == e
+}
+
+// lookupJSONPath resolves a simple JSONPath such as $.data.items[0].status.
+func lookupJSONPath(doc any, path string) (any, bool, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	current := doc
+
+	for path != "" {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+
+			key := path[:end]
+			path = path[end:]
+			if key == "" {
+				return nil, false, fmt.Errorf("empty key in path")
+			}
+
+			obj, ok := current.(map[string]any)
+			if !ok {
+				return nil, false, nil
+			}
+			if current, ok = obj[key]; !ok {
+				return nil, false, nil
+			}
+
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				return nil, false, fmt.Errorf("unterminated index")
+			}
+
+			segment := path[1:end]
+			path = path[end+1:]
+
+			if quoted := strings.Trim(segment, `'"`); quoted != segment {
+				obj, ok := current.(map[string]any)
+				if !ok {
+					return nil, false, nil
+				}
+				if current, ok = obj[quoted]; !ok {
+					return nil, false, nil
+				}
+				continue
+			}
+
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid index %q", segment)
+			}
+
+			arr, ok := current.([]any)
+			if !ok {
+				return nil, false, nil
+			}
+			if index < 0 {
+				index += len(arr)
+			}
+			if index < 0 || index >= len(arr) {
+				return nil, false, nil
+			}
+			current = arr[index]
+
+		default:
+			return nil, false, fmt.Errorf("unexpected character %q", path[0])
+		}
+	}
+
+	return current, true, nil
+}
+
+func jsonValueString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
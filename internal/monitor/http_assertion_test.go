@@ -0,0 +1,104 @@
+package monitor
+
+import (
+	"net/http"
+	"testing"
+
+	"honk/internal/database"
+)
+
+func TestEvaluateAssertion(t *testing.T) {
+	resp := httpResponse{
+		StatusCode: http.StatusCreated,
+		Header: http.Header{
+			"Content-Type": {"application/json"},
+			"X-Version":    {"1.4.2"},
+		},
+		Body:           []byte(`{"status":"ok","data":{"items":[{"id":1,"ready":true},{"id":2,"ready":false}],"load":0.75,"owner":null,"key.with.dots":"x"}}`),
+		ResponseTimeMs: 120,
+	}
+
+	assertion := func(typ database.AssertionType, target string, op database.AssertionOperator, value string) database.HttpMonitorAssertion {
+		return database.HttpMonitorAssertion{Type: typ, Target: target, Operator: op, Value: value}
+	}
+
+	tests := []struct {
+		name      string
+		assertion database.HttpMonitorAssertion
+		ok        bool
+	}{
+		{"status in range", assertion(database.AssertionStatusCode, "", "", "200-299"), true},
+		{"status in list", assertion(database.AssertionStatusCode, "", "", "200, 201,204"), true},
+		{"status outside", assertion(database.AssertionStatusCode, "", "", "200,300-399"), false},
+		{"status invalid", assertion(database.AssertionStatusCode, "", "", "2xx"), false},
+
+		{"body contains", assertion(database.AssertionBodyContains, "", "", `"status":"ok"`), true},
+		{"body misses", assertion(database.AssertionBodyContains, "", "", "error"), false},
+		{"body does not contain", assertion(database.AssertionBodyNotContains, "", "", "error"), true},
+		{"body contains forbidden", assertion(database.AssertionBodyNotContains, "", "", "ready"), false},
+		{"body regex", assertion(database.AssertionBodyRegex, "", "", `"id":\d+`), true},
+		{"body regex invalid", assertion(database.AssertionBodyRegex, "", "", `(`), false},
+
+		{"json equal", assertion(database.AssertionJSONPath, "$.status", database.OperatorEqual, "ok"), true},
+		{"json default operator", assertion(database.AssertionJSONPath, "$.status", "", "ok"), true},
+		{"json index", assertion(database.AssertionJSONPath, "$.data.items[1].id", database.OperatorEqual, "2"), true},
+		{"json negative index", assertion(database.AssertionJSONPath, "$.data.items[-1].ready", database.OperatorEqual, "false"), true},
+		{"json quoted key", assertion(database.AssertionJSONPath, `$.data['key.with.dots']`, database.OperatorEqual, "x"), true},
+		{"json null", assertion(database.AssertionJSONPath, "$.data.owner", database.OperatorEqual, "null"), true},
+		{"json numbers compare numerically", assertion(database.AssertionJSONPath, "$.data.load", database.OperatorEqual, "0.750"), true},
+		{"json greater", assertion(database.AssertionJSONPath, "$.data.load", database.OperatorGreater, "0.5"), true},
+		{"json less fails", assertion(database.AssertionJSONPath, "$.data.load", database.OperatorLess, "0.5"), false},
+		{"json ordering needs numbers", assertion(database.AssertionJSONPath, "$.status", database.OperatorGreater, "1"), false},
+		{"json exists", assertion(database.AssertionJSONPath, "$.data.items[0]", database.OperatorExists, ""), true},
+		{"json missing", assertion(database.AssertionJSONPath, "$.data.missing", database.OperatorExists, ""), false},
+		{"json out of range", assertion(database.AssertionJSONPath, "$.data.items[5]", database.OperatorExists, ""), false},
+		{"json invalid path", assertion(database.AssertionJSONPath, "$.data.items[0", database.OperatorExists, ""), false},
+		{"json not equal", assertion(database.AssertionJSONPath, "$.status", database.OperatorNotEqual, "down"), true},
+
+		{"header matches", assertion(database.AssertionHeader, "x-version", database.OperatorMatches, `^1\.\d+`), true},
+		{"header contains", assertion(database.AssertionHeader, "Content-Type", database.OperatorContains, "json"), true},
+		{"header does not contain", assertion(database.AssertionHeader, "Content-Type", database.OperatorNotContains, "json"), false},
+		{"header missing", assertion(database.AssertionHeader, "X-Missing", database.OperatorExists, ""), false},
+		{"header unknown operator", assertion(database.AssertionHeader, "X-Version", "like", "1"), false},
+
+		{"response time within", assertion(database.AssertionResponseTime, "", "", "120"), true},
+		{"response time exceeded", assertion(database.AssertionResponseTime, "", "", "100"), false},
+		{"response time invalid", assertion(database.AssertionResponseTime, "", "", "fast"), false},
+
+		{"unknown type", assertion("cookie", "", "", ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := evaluateAssertion(tt.assertion, resp)
+			if (err == nil) != tt.ok {
+				t.Errorf("expected ok %v, got %v", tt.ok, err)
+			}
+		})
+	}
+}
+
+func TestEvaluateAssertionInvalidJSON(t *testing.T) {
+	a := database.HttpMonitorAssertion{Type: database.AssertionJSONPath, Target: "$.status", Operator: database.OperatorExists}
+	if err := evaluateAssertion(a, httpResponse{Body: []byte("<html>")}); err == nil {
+		t.Error("expected a body that is not JSON to fail")
+	}
+}
+
+func TestAssertionHelpers(t *testing.T) {
+	status := []database.HttpMonitorAssertion{{Type: database.AssertionStatusCode, Value: "200"}}
+	body := []database.HttpMonitorAssertion{{Type: database.AssertionJSONPath, Target: "$.ok", Operator: database.OperatorExists}}
+	header := []database.HttpMonitorAssertion{{Type: database.AssertionHeader, Target: "X-Version"}}
+
+	if !hasStatusAssertion(status) || hasStatusAssertion(body) {
+		t.Error("expected only status assertions to replace the default status rule")
+	}
+	if !needsBody(body) || needsBody(header) || needsBody(status) {
+		t.Error("expected only body assertions to need the body")
+	}
+
+	failures := evaluateAssertions(append(status, body...), httpResponse{StatusCode: 500, Body: []byte(`{"ok":true}`)})
+	if len(failures) != 1 {
+		t.Errorf("expected one failure for the status, got %v", failures)
+	}
+}
@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"honk/internal/database"
+	"honk/internal/notification"
+	"io"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// BodyTemplateData holds the variables available to request body templates,
+// e.g. {"checkedAt": "{{.Timestamp}}", "monitor": {{json .Name}}}.
+type BodyTemplateData struct {
+	Name      string
+	Timestamp string
+	Unix      int64
+	Nonce     string
+}
+
+func newBodyTemplateData(m *database.Monitor, now time.Time) (*BodyTemplateData, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return &BodyTemplateData{
+		Name:      m.Name,
+		Timestamp: now.Format(time.RFC3339),
+		Unix:      now.Unix(),
+		Nonce:     hex.EncodeToString(nonce),
+	}, nil
+}
+
+// buildRequestBody renders the monitor body for a single check and returns it
+// together with the content type that should be sent with it.
+func buildRequestBody(m *database.Monitor, now time.Time) (io.Reader, string, error) {
+	if m.Body == "" {
+		return nil, "", nil
+	}
+
+	data, err := newBodyTemplateData(m, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tmpl, err := template.New("body").Funcs(notification.TemplateFuncs).Parse(m.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid body template: %w", err)
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return nil, "", fmt.Errorf("failed to render body template: %w", err)
+	}
+
+	var (
+		payload     = rendered.Bytes()
+		contentType string
+	)
+
+	switch m.BodyType {
+	case database.BodyTypeJSON:
+		if !json.Valid(payload) {
+			return nil, "", fmt.Errorf("rendered body is not valid JSON")
+		}
+		contentType = "application/json"
+	case database.BodyTypeForm:
+		values, err := parseFormBody(rendered.String())
+		if err != nil {
+			return nil, "", err
+		}
+		payload = []byte(values.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case database.BodyTypeRaw, database.BodyTypeNone:
+		contentType = "text/plain; charset=utf-8"
+	default:
+		return nil, "", fmt.Errorf("unsupported body type %q", m.BodyType)
+	}
+
+	if m.ContentType != "" {
+		contentType = m.ContentType
+	}
+
+	return bytes.NewReader(payload), contentType, nil
+}
+
+// parseFormBody parses one key=value pair per line into form values.
+func parseFormBody(body string) (url.Values, error) {
+	values := url.Values{}
+
+	for line := range strings.Lines(body) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid form field %q, expected key=value", line)
+		}
+		values.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+
+	return values, nil
+}
@@ -11,24 +11,38 @@ import (
 
 const (
 	DEFAULT_TIMEOUT = 30 * time.Second
+
+	maxSavedBodySize     = 1024
+	maxAssertionBodySize = 1 << 20
 )
 
-type HTTPPingHandler struct{}
+type HTTPPingHandler struct {
+	// Timeout of monitors without their own timeout
+	DefaultTimeout time.Duration
+}
 
-func NewHTTPPingHandler() *HTTPPingHandler {
-	return &HTTPPingHandler{}
+func NewHTTPPingHandler(defaultTimeout time.Duration) *HTTPPingHandler {
+	if defaultTimeout <= 0 {
+		defaultTimeout = DEFAULT_TIMEOUT
+	}
+	return &HTTPPingHandler{DefaultTimeout: defaultTimeout}
 }
 
 func (h *HTTPPingHandler) Check(ctx context.Context, m *database.Monitor) (string, int64, error) {
 	timeout := time.Duration(m.Timeout) * time.Second
 	if timeout <= 0 {
-		timeout = DEFAULT_TIMEOUT
+		timeout = h.DefaultTimeout
 	}
 
 	checkCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(checkCtx, m.HTTPMethod, m.Connection, nil)
+	body, contentType, err := buildRequestBody(m, time.Now())
+	if err != nil {
+		return fmt.Sprintf("Failed to build request body for %s: %v", m.Connection, err), 0, err
+	}
+
+	req, err := http.NewRequestWithContext(checkCtx, m.HTTPMethod, m.Connection, body)
 	if err != nil {
 		return fmt.Sprintf("Failed to create request to %s: %v", m.Connection, err), 0, err
 	}
@@ -39,6 +53,10 @@ func (h *HTTPPingHandler) Check(ctx context.Context, m *database.Monitor) (strin
 		req.Header.Add(header.Key, header.Value)
 	}
 
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
 	client := &http.Client{Timeout: timeout}
 
 	start := time.Now()
@@ -54,20 +72,66 @@ func (h *HTTPPingHandler) Check(ctx context.Context, m *database.Monitor) (strin
 		}
 	}()
 
-	var bodyMsg string
-	if resp.StatusCode >= 400 || m.AlwaysSave {
+	var (
+		assertions    = m.HttpMonitorAssertions
+		statusFailure = resp.StatusCode >= 400 && !hasStatusAssertion(assertions)
+		bodyBytes     []byte
+	)
+
+	if statusFailure || m.AlwaysSave || len(assertions) > 0 {
 		// Limit body reading to avoid huge responses
-		limitReader := io.LimitReader(resp.Body, 1024)
-		if bodyBytes, readErr := io.ReadAll(limitReader); readErr == nil && len(bodyBytes) > 0 {
-			bodyMsg = "\n" + string(bodyBytes)
+		limit := int64(maxSavedBodySize)
+		if needsBody(assertions) {
+			limit = maxAssertionBodySize
+		}
+		if b, readErr := io.ReadAll(io.LimitReader(resp.Body, limit)); readErr == nil {
+			bodyBytes = b
 		}
 	}
 
-	if resp.StatusCode >= 400 {
+	var bodyMsg string
+	if len(bodyBytes) > 0 {
+		bodyMsg = "\n" + string(bodyBytes[:min(len(bodyBytes), maxSavedBodySize)])
+	}
+
+	if statusFailure {
 		errMsg := fmt.Sprintf("HTTP %d %s after %dms from %s", resp.StatusCode, http.StatusText(resp.StatusCode), duration, m.Connection)
 		return errMsg + bodyMsg, duration, fmt.Errorf("http status %d", resp.StatusCode)
 	}
 
+	failures := evaluateAssertions(assertions, httpResponse{
+		StatusCode:     resp.StatusCode,
+		Header:         resp.Header,
+		Body:           bodyBytes,
+		ResponseTimeMs: duration,
+	})
+	if len(failures) > 0 {
+		errMsg := fmt.Sprintf("%d of %d assertions failed for %s (HTTP %d after %dms)", len(failures), len(assertions), m.Connection, resp.StatusCode, duration)
+		for _, failure := range failures {
+			errMsg += "\n- " + failure
+		}
+		return errMsg + bodyMsg, duration, fmt.Errorf("%d assertion(s) failed", len(failures))
+	}
+
+	if resp.TLS != nil {
+		report, certErr := inspectCertificates(resp.TLS.PeerCertificates, req.URL.Hostname(), m.CertExpiryDays, false, time.Now())
+		if report.Leaf != nil {
+			checkDetails(ctx).CertExpiresAt = &report.Leaf.NotAfter
+		}
+
+		if m.CertExpiryDays > 0 && certErr != nil {
+			if !m.CertExpiryWarnOnly {
+				return fmt.Sprintf("%v\n\n%s", certErr, report), duration, certErr
+			}
+			log.Warning("certificate warning for monitor '%s': %v", m.Name, certErr)
+			return fmt.Sprintf("Warning: %v\n\n%s", certErr, report), duration, fmt.Errorf("%w: %v", ErrDegraded, certErr)
+		}
+
+		if m.AlwaysSave {
+			bodyMsg = "\n" + report.String() + "\n" + bodyMsg
+		}
+	}
+
 	if m.AlwaysSave {
 		return bodyMsg, duration, nil
 	}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"honk/internal/database"
+	"time"
+)
+
+func (m *Manager) openIncident(mon *database.Monitor, at time.Time, cause string) {
+	incident := database.Incident{
+		MonitorID: mon.ID,
+		StartedAt: at,
+		Cause:     cause,
+	}
+
+	if err := m.db.Create(&incident).Error; err != nil {
+		log.Error("failed to open incident for monitor %d: %v", mon.ID, err)
+	}
+}
+
+func (m *Manager) resolveIncident(mon *database.Monitor, at time.Time) {
+	err := m.db.Model(&database.Incident{}).
+		Where("monitor_id = ? AND resolved_at IS NULL", mon.ID).
+		Update("resolved_at", at).Error
+	if err != nil {
+		log.Error("failed to resolve incident for monitor %d: %v", mon.ID, err)
+	}
+}
+
+var ErrIncidentNotFound = errors.New("incident not found")
+
+func (m *Manager) ListIncidents(monitorID uint) ([]database.Incident, error) {
+	incidents := []database.Incident{}
+	err := m.db.Where("monitor_id = ?", monitorID).Order("started_at DESC").Find(&incidents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load incidents for monitor %d: %w", monitorID, err)
+	}
+	return incidents, nil
+}
+
+// AcknowledgeIncident marks that someone is looking into the incident.
+func (m *Manager) AcknowledgeIncident(id, userID uint, access Access) (*database.Incident, error) {
+	var incident database.Incident
+	if err := m.db.Limit(1).Find(&incident, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to load incident %d: %w", id, err)
+	}
+
+	m.mu.Lock()
+	mon, ok := m.monitors[int(incident.MonitorID)]
+	visible := ok && access.CanSee(mon)
+	m.mu.Unlock()
+
+	if incident.ID == 0 || !visible {
+		return nil, ErrIncidentNotFound
+	}
+
+	now := time.Now()
+	incident.AcknowledgedAt = &now
+	incident.AcknowledgedBy = &userID
+
+	err := m.db.Model(&incident).Updates(map[string]any{
+		"acknowledged_at": incident.AcknowledgedAt,
+		"acknowledged_by": incident.AcknowledgedBy,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to acknowledge incident %d: %w", id, err)
+	}
+
+	return &incident, nil
+}
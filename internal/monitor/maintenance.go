@@ -0,0 +1,284 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"honk/internal/database"
+	"slices"
+	"time"
+)
+
+func (m *Manager) loadMaintenanceFromDB() {
+	var windows []database.MaintenanceWindow
+	if err := m.db.Find(&windows).Error; err != nil {
+		log.Error("failed to load maintenance windows from database: %v", err)
+		return
+	}
+
+	m.mu.Lock()
+	m.maintenance = windows
+	m.mu.Unlock()
+}
+
+var ErrMaintenanceNotFound = errors.New("maintenance window not found")
+
+// ListMaintenance returns the maintenance windows visible to the access.
+func (m *Manager) ListMaintenance(access Access) []database.MaintenanceWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	windows := []database.MaintenanceWindow{}
+	for _, window := range m.maintenance {
+		if access.CanSeeMaintenance(&window) {
+			windows = append(windows, window)
+		}
+	}
+	return windows
+}
+
+func (m *Manager) GetMaintenance(id uint, access Access) *database.MaintenanceWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.maintenance {
+		if m.maintenance[i].ID == id && access.CanSeeMaintenance(&m.maintenance[i]) {
+			window := m.maintenance[i]
+			return &window
+		}
+	}
+	return nil
+}
+
+// AddMaintenance saves a new window, windows of callers without full access
+// belong to their teams.
+func (m *Manager) AddMaintenance(window *database.MaintenanceWindow, access Access) error {
+	if !access.All {
+		window.TeamIDs = slices.Clone(access.TeamIDs)
+	}
+	if err := m.validateMaintenanceAccess(window, access); err != nil {
+		return err
+	}
+	if err := validateMaintenance(window); err != nil {
+		return err
+	}
+
+	if err := m.db.Create(window).Error; err != nil {
+		return fmt.Errorf("failed to save maintenance window: %w", err)
+	}
+
+	m.mu.Lock()
+	m.maintenance = append(m.maintenance, *window)
+	m.mu.Unlock()
+
+	log.Info("maintenance window added: %s", window.Name)
+	return nil
+}
+
+// UpdateMaintenance replaces a window visible to the access. The teams of the
+// window can only be changed with full access.
+func (m *Manager) UpdateMaintenance(window *database.MaintenanceWindow, access Access) error {
+	current := m.GetMaintenance(window.ID, access)
+	if current == nil {
+		return fmt.Errorf("%w: %d", ErrMaintenanceNotFound, window.ID)
+	}
+
+	if !access.All {
+		window.TeamIDs = current.TeamIDs
+	}
+	if err := m.validateMaintenanceAccess(window, access); err != nil {
+		return err
+	}
+	if err := validateMaintenance(window); err != nil {
+		return err
+	}
+
+	if err := m.db.Save(window).Error; err != nil {
+		return fmt.Errorf("failed to update maintenance window %d: %w", window.ID, err)
+	}
+
+	m.loadMaintenanceFromDB()
+
+	log.Info("maintenance window updated: %s (ID: %d)", window.Name, window.ID)
+	return nil
+}
+
+func (m *Manager) RemoveMaintenance(id uint, access Access) error {
+	if m.GetMaintenance(id, access) == nil {
+		return fmt.Errorf("%w: %d", ErrMaintenanceNotFound, id)
+	}
+
+	result := m.db.Delete(&database.MaintenanceWindow{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete maintenance window %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %d", ErrMaintenanceNotFound, id)
+	}
+
+	m.loadMaintenanceFromDB()
+
+	log.Info("maintenance window removed: %d", id)
+	return nil
+}
+
+// validateMaintenanceAccess rejects windows targeting monitors the access
+// cannot modify, unknown monitors are treated the same way.
+func (m *Manager) validateMaintenanceAccess(window *database.MaintenanceWindow, access Access) error {
+	if access.All {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range window.MonitorIDs {
+		mon, ok := m.monitors[int(id)]
+		if !ok || !access.CanSee(mon) {
+			return fmt.Errorf("monitor %d does not exist", id)
+		}
+		if !access.CanModify(mon) {
+			return fmt.Errorf("monitor %d is shared, only admins can put it into maintenance", id)
+		}
+	}
+	return nil
+}
+
+// activeMaintenance returns the maintenance window covering the monitor at
+// the given time, or nil when there is none.
+func (m *Manager) activeMaintenance(mon *database.Monitor, at time.Time) *database.MaintenanceWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.findMaintenance(mon, at)
+}
+
+// findMaintenance is activeMaintenance for callers already holding m.mu.
+func (m *Manager) findMaintenance(mon *database.Monitor, at time.Time) *database.MaintenanceWindow {
+	for i := range m.maintenance {
+		window := m.maintenance[i]
+		if targetsMonitor(window, mon) && maintenanceActive(window, at) {
+			return &window
+		}
+	}
+	return nil
+}
+
+func validateMaintenance(window *database.MaintenanceWindow) error {
+	if !window.EndsAt.After(window.StartsAt) {
+		return fmt.Errorf("maintenance window must end after it starts")
+	}
+
+	if window.Mode == "" {
+		window.Mode = database.MaintenanceSkip
+	}
+	if window.Mode != database.MaintenanceSkip && window.Mode != database.MaintenanceRecord {
+		return fmt.Errorf("unknown maintenance mode %q", window.Mode)
+	}
+
+	if _, err := time.LoadLocation(window.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", window.Timezone)
+	}
+
+	duration := window.EndsAt.Sub(window.StartsAt)
+	switch window.Recurrence {
+	case database.RecurrenceNone:
+	case database.RecurrenceDaily:
+		if duration > 24*time.Hour {
+			return fmt.Errorf("daily maintenance windows cannot be longer than a day")
+		}
+	case database.RecurrenceWeekly:
+		if duration > 7*24*time.Hour {
+			return fmt.Errorf("weekly maintenance windows cannot be longer than a week")
+		}
+	case database.RecurrenceMonthly:
+		if duration > 28*24*time.Hour {
+			return fmt.Errorf("monthly maintenance windows cannot be longer than 28 days")
+		}
+	default:
+		return fmt.Errorf("unknown recurrence %q", window.Recurrence)
+	}
+
+	if len(window.MonitorIDs) == 0 && len(window.Tags) == 0 {
+		return fmt.Errorf("maintenance window must target at least one monitor or tag")
+	}
+
+	return nil
+}
+
+func targetsMonitor(window database.MaintenanceWindow, mon *database.Monitor) bool {
+	// Windows of teams leave shared monitors and those of other teams alone,
+	// also when they share a tag
+	if len(window.TeamIDs) > 0 && !(Access{TeamIDs: window.TeamIDs}).CanModify(mon) {
+		return false
+	}
+
+	if slices.Contains(window.MonitorIDs, mon.ID) {
+		return true
+	}
+
+	for _, tag := range window.Tags {
+		if slices.Contains(mon.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenanceActive reports whether any occurrence of the window covers at.
+// Occurrences of recurring windows keep the wall clock time of the first one
+// in the window's timezone, so they follow daylight saving changes.
+func maintenanceActive(window database.MaintenanceWindow, at time.Time) bool {
+	if at.Before(window.StartsAt) {
+		return false
+	}
+	if window.Recurrence == database.RecurrenceNone {
+		return at.Before(window.EndsAt)
+	}
+
+	loc, err := time.LoadLocation(window.Timezone)
+	if err != nil {
+		return false
+	}
+
+	var (
+		first    = window.StartsAt.In(loc)
+		local    = at.In(loc)
+		duration = window.EndsAt.Sub(window.StartsAt)
+		lookback = int(duration/(24*time.Hour)) + 1
+	)
+
+	for daysBack := 0; daysBack <= lookback; daysBack++ {
+		day := local.AddDate(0, 0, -daysBack)
+
+		switch window.Recurrence {
+		case database.RecurrenceWeekly:
+			if day.Weekday() != first.Weekday() {
+				continue
+			}
+		case database.RecurrenceMonthly:
+			// Windows starting on the 29th to 31st fall on the last day of
+			// shorter months
+			if day.Day() != min(first.Day(), daysIn(day.Year(), day.Month())) {
+				continue
+			}
+		}
+
+		start := time.Date(day.Year(), day.Month(), day.Day(), first.Hour(), first.Minute(), first.Second(), 0, loc)
+		if start.Before(window.StartsAt) {
+			continue
+		}
+		if window.RepeatUntil != nil && start.After(*window.RepeatUntil) {
+			continue
+		}
+
+		if !at.Before(start) && at.Before(start.Add(duration)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"honk/internal/database"
+)
+
+func TestMaintenanceActive(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data is not available: %v", err)
+	}
+
+	utc := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	window := func(recurrence database.Recurrence, start time.Time, duration time.Duration) database.MaintenanceWindow {
+		return database.MaintenanceWindow{
+			StartsAt:   start,
+			EndsAt:     start.Add(duration),
+			Recurrence: recurrence,
+			Timezone:   start.Location().String(),
+		}
+	}
+	until := utc(2026, 3, 5, 0, 0)
+
+	var (
+		once        = window(database.RecurrenceNone, utc(2026, 3, 1, 22, 0), 2*time.Hour)
+		daily       = window(database.RecurrenceDaily, utc(2026, 3, 1, 23, 0), 2*time.Hour)
+		weekly      = window(database.RecurrenceWeekly, utc(2026, 3, 2, 4, 0), time.Hour) // Monday
+		monthly     = window(database.RecurrenceMonthly, utc(2026, 1, 31, 1, 0), time.Hour)
+		dst         = window(database.RecurrenceDaily, time.Date(2026, 3, 27, 2, 30, 0, 0, berlin), time.Hour)
+		repeatUntil = daily
+	)
+	repeatUntil.RepeatUntil = &until
+
+	tests := []struct {
+		name   string
+		window database.MaintenanceWindow
+		at     time.Time
+		want   bool
+	}{
+		{"once before", once, utc(2026, 3, 1, 21, 59), false},
+		{"once during", once, utc(2026, 3, 1, 23, 30), true},
+		{"once at the end", once, utc(2026, 3, 2, 0, 0), false},
+
+		{"daily first occurrence", daily, utc(2026, 3, 1, 23, 30), true},
+		{"daily across midnight", daily, utc(2026, 3, 10, 0, 30), true},
+		{"daily outside", daily, utc(2026, 3, 10, 12, 0), false},
+		{"daily before the first occurrence", daily, utc(2026, 3, 1, 0, 30), false},
+
+		{"weekly on the weekday", weekly, utc(2026, 3, 16, 4, 30), true},
+		{"weekly on another weekday", weekly, utc(2026, 3, 17, 4, 30), false},
+
+		{"monthly on the day", monthly, utc(2026, 3, 31, 1, 30), true},
+		{"monthly clamped to the end of february", monthly, utc(2026, 2, 28, 1, 30), true},
+		{"monthly clamped to the end of april", monthly, utc(2026, 4, 30, 1, 30), true},
+		{"monthly not on the day", monthly, utc(2026, 3, 30, 1, 30), false},
+		{"monthly leap year", window(database.RecurrenceMonthly, utc(2027, 12, 30, 1, 0), time.Hour), utc(2028, 2, 29, 1, 30), true},
+
+		{"repeat until includes the last occurrence", repeatUntil, utc(2026, 3, 4, 23, 30), true},
+		{"repeat until stops", repeatUntil, utc(2026, 3, 5, 23, 30), false},
+
+		{"daylight saving keeps the wall clock", dst, time.Date(2026, 3, 30, 2, 45, 0, 0, berlin), true},
+		{"daylight saving moves the utc time", dst, utc(2026, 3, 30, 1, 45), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maintenanceActive(tt.window, tt.at); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateMaintenance(t *testing.T) {
+	start := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		recurrence database.Recurrence
+		duration   time.Duration
+		timezone   string
+		valid      bool
+	}{
+		{"once", database.RecurrenceNone, 72 * time.Hour, "UTC", true},
+		{"ends before it starts", database.RecurrenceNone, -time.Hour, "UTC", false},
+		{"daily longer than a day", database.RecurrenceDaily, 25 * time.Hour, "UTC", false},
+		{"weekly of a week", database.RecurrenceWeekly, 7 * 24 * time.Hour, "UTC", true},
+		{"monthly longer than february", database.RecurrenceMonthly, 29 * 24 * time.Hour, "UTC", false},
+		{"unknown recurrence", "yearly", time.Hour, "UTC", false},
+		{"unknown timezone", database.RecurrenceDaily, time.Hour, "Mars/Olympus", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := &database.MaintenanceWindow{
+				StartsAt:   start,
+				EndsAt:     start.Add(tt.duration),
+				Recurrence: tt.recurrence,
+				Timezone:   tt.timezone,
+				Tags:       []string{"db"},
+			}
+			if err := validateMaintenance(window); (err == nil) != tt.valid {
+				t.Errorf("expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
@@ -2,21 +2,59 @@ package monitor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"honk/internal/database"
 	"honk/internal/notification"
-	"maps"
 	"sync"
 	"time"
 
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 type Handler interface {
 	Check(ctx context.Context, m *database.Monitor) (string, int64, error)
 }
 
+// Forgetter is implemented by handlers keeping state per monitor, the state is
+// dropped when the monitor is changed or removed.
+type Forgetter interface {
+	Forget(id uint)
+}
+
+// ErrSkipCheck can be returned by a handler when there is nothing to record,
+// e.g. when a push monitor is still within its deadline.
+var ErrSkipCheck = errors.New("check skipped")
+
+// ErrDegraded is wrapped by handlers when a check passed with a warning, the
+// monitor stays up but is marked degraded.
+var ErrDegraded = errors.New("degraded")
+
+var ErrInvalidMonitor = errors.New("invalid monitor")
+
+// CheckDetails holds what a check found out about the monitor besides its
+// result. Handlers must not change the shared monitor, they fill the details
+// of the context instead and the manager applies them under its lock.
+type CheckDetails struct {
+	CertExpiresAt *time.Time
+}
+
+type checkDetailsKey struct{}
+
+func withCheckDetails(ctx context.Context) (context.Context, *CheckDetails) {
+	details := &CheckDetails{}
+	return context.WithValue(ctx, checkDetailsKey{}, details), details
+}
+
+// checkDetails returns the details of the check, they are discarded when the
+// handler is called outside of the manager.
+func checkDetails(ctx context.Context) *CheckDetails {
+	if details, ok := ctx.Value(checkDetailsKey{}).(*CheckDetails); ok {
+		return details
+	}
+	return &CheckDetails{}
+}
+
 type monitorRunner struct {
 	cancel context.CancelFunc
 	done   chan struct{}
@@ -29,6 +67,25 @@ type Manager struct {
 	runners  map[int]*monitorRunner
 	handlers map[database.ConnectionType]Handler
 
+	// checkLocks serialize the checks of a monitor with the pings of its
+	// job, both change the monitor state outside of mu
+	checkLocks map[int]*sync.Mutex
+
+	maintenance []database.MaintenanceWindow
+	retention   RetentionPolicy
+	syncFile    string
+	smtp        notification.SMTPSettings
+
+	subscribersMu  sync.Mutex
+	subscribers    map[int]chan Event
+	nextSubscriber int
+
+	statsMu    sync.Mutex
+	statsCache map[statsKey]cachedStats
+
+	notificationsMu sync.Mutex
+	notifications   map[uint]*notificationCounts
+
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
@@ -38,35 +95,39 @@ func NewManager(db *gorm.DB) *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	mgr := &Manager{
-		db:       db,
-		monitors: make(map[int]*database.Monitor),
-		runners:  make(map[int]*monitorRunner),
-		handlers: make(map[database.ConnectionType]Handler),
-		ctx:      ctx,
-		cancel:   cancel,
+		db:            db,
+		monitors:      make(map[int]*database.Monitor),
+		runners:       make(map[int]*monitorRunner),
+		checkLocks:    make(map[int]*sync.Mutex),
+		handlers:      make(map[database.ConnectionType]Handler),
+		statsCache:    make(map[statsKey]cachedStats),
+		retention:     DefaultRetentionPolicy(),
+		notifications: make(map[uint]*notificationCounts),
+		subscribers:   make(map[int]chan Event),
+		ctx:           ctx,
+		cancel:        cancel,
 	}
 
 	return mgr
 }
 
 func (m *Manager) Start() {
+	m.loadMaintenanceFromDB()
 	m.loadMonitorsFromDB()
+
+	m.wg.Add(1)
+	go m.runRetention()
 }
 
 func (m *Manager) loadMonitorsFromDB() {
 	var dbMonitors []database.Monitor
-	if err := m.db.Preload(clause.Associations).Find(&dbMonitors).Error; err != nil {
+	if err := preloadMonitor(m.db).Find(&dbMonitors).Error; err != nil {
 		log.Error("failed to load monitors from database: %v", err)
 		return
 	}
 
 	for i := range dbMonitors {
 		mon := &dbMonitors[i]
-
-		for j := range mon.Checks {
-			mon.Checks[j].Result = ""
-		}
-
 		m.monitors[int(mon.ID)] = mon
 		m.startMonitor(int(mon.ID))
 	}
@@ -74,6 +135,12 @@ func (m *Manager) loadMonitorsFromDB() {
 	log.Info("%d monitors loaded from database", len(dbMonitors))
 }
 
+// preloadMonitor loads the configuration associations of monitors, the check
+// history is left out as it can grow large.
+func preloadMonitor(db *gorm.DB) *gorm.DB {
+	return db.Preload("HttpMonitorHeaders").Preload("HttpMonitorAssertions").Preload("Notifications")
+}
+
 func (m *Manager) RegisterHandler(ct database.ConnectionType, h Handler) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -93,6 +160,18 @@ func (m *Manager) AddMonitor(mon *database.Monitor) (*database.Monitor, error) {
 		return nil, fmt.Errorf("no handler registered for connection type %s", mon.ConnectionType)
 	}
 
+	if mon.ConnectionType == database.ConnectionTypePush {
+		token, err := generatePushToken()
+		if err != nil {
+			m.mu.Unlock()
+			return nil, err
+		}
+		mon.PushToken = token
+		if mon.Connection == "" {
+			mon.Connection = token
+		}
+	}
+
 	for _, existing := range m.monitors {
 		if existing.Connection == mon.Connection {
 			m.mu.Unlock()
@@ -100,9 +179,31 @@ func (m *Manager) AddMonitor(mon *database.Monitor) (*database.Monitor, error) {
 		}
 	}
 
+	if err := m.validateKey(mon.ID, mon.Key); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+
+	if err := m.validateParents(mon.ID, mon.ParentIDs); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+
 	m.mu.Unlock()
 
-	if err := m.db.Create(mon).Error; err != nil {
+	links, err := m.prepareLinks(mon, mon.Notifications)
+	if err != nil {
+		return nil, err
+	}
+	mon.Notifications = links
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Notifications").Create(mon).Error; err != nil {
+			return err
+		}
+		return saveLinks(tx, mon.ID, mon.Notifications)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to save new monitor: %w", err)
 	}
 
@@ -110,82 +211,138 @@ func (m *Manager) AddMonitor(mon *database.Monitor) (*database.Monitor, error) {
 	m.monitors[int(mon.ID)] = mon
 	m.mu.Unlock()
 
+	// The runner changes the monitor once started, callers get a copy
+	snapshot := snapshotMonitor(mon)
+	m.publishMonitor(EventMonitorCreated, snapshot)
 	m.startMonitor(int(mon.ID))
 
 	log.Info("New monitor added: %s", mon.Name)
-	return mon, nil
+	return snapshot, nil
 }
 
 func (m *Manager) UpdateMonitor(updated *database.Monitor) error {
 	m.mu.Lock()
 	existing, exists := m.monitors[int(updated.ID)]
+	m.mu.Unlock()
 	if !exists {
-		m.mu.Unlock()
 		return fmt.Errorf("monitor %d does not exist", updated.ID)
 	}
 
-	if runner, ok := m.runners[int(updated.ID)]; ok {
-		runner.cancel()
-		<-runner.done
-		delete(m.runners, int(updated.ID))
+	m.mu.Lock()
+	err := m.validateParents(updated.ID, updated.ParentIDs)
+	if err == nil {
+		err = m.validateKey(updated.ID, updated.Key)
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Links are kept when a client leaves them out, they are still checked as
+	// the monitor may have moved to another team
+	links := updated.Notifications
+	if links == nil {
+		m.mu.Lock()
+		links = append([]database.MonitorNotification{}, existing.Notifications...)
+		m.mu.Unlock()
+	}
+	if links, err = m.prepareLinks(updated, links); err != nil {
+		return err
 	}
 
+	m.stopRunner(int(updated.ID))
+	m.forget(updated.ID)
+
+	m.mu.Lock()
+	// Clients unaware of keys leave them empty, which keeps the monitor
+	// managed by its desired state file
+	if updated.Key != "" {
+		existing.Key = updated.Key
+	}
 	existing.Enabled = updated.Enabled
 	existing.Name = updated.Name
+	existing.Tags = updated.Tags
+	existing.ParentIDs = updated.ParentIDs
+	existing.TeamID = updated.TeamID
 	existing.Connection = updated.Connection
 	existing.Interval = updated.Interval
 	existing.AlwaysSave = updated.AlwaysSave
 	existing.ConnectionType = updated.ConnectionType
 	existing.Timeout = updated.Timeout
 	existing.Body = updated.Body
+	existing.BodyType = updated.BodyType
+	existing.ContentType = updated.ContentType
 	existing.HTTPMethod = updated.HTTPMethod
 	existing.HttpMonitorHeaders = updated.HttpMonitorHeaders
-	existing.Notification = updated.Notification
+	existing.HttpMonitorAssertions = updated.HttpMonitorAssertions
+	existing.CertExpiryDays = updated.CertExpiryDays
+	existing.CertExpiryWarnOnly = updated.CertExpiryWarnOnly
+	existing.DNSRecordType = updated.DNSRecordType
+	existing.DNSResolver = updated.DNSResolver
+	existing.DNSMatch = updated.DNSMatch
+	existing.DNSExpected = updated.DNSExpected
+	existing.DNSMinTTL = updated.DNSMinTTL
+	existing.DNSMaxTTL = updated.DNSMaxTTL
+	existing.ContainerFailOnUnhealthy = updated.ContainerFailOnUnhealthy
+	existing.ContainerMaxRestarts = updated.ContainerMaxRestarts
+	existing.ContainerRestartWindow = updated.ContainerRestartWindow
+	existing.PingCount = updated.PingCount
+	existing.PingLossThreshold = updated.PingLossThreshold
+	existing.GracePeriod = updated.GracePeriod
+	existing.RetriesBeforeDown = updated.RetriesBeforeDown
+	existing.RetryInterval = updated.RetryInterval
+	existing.SuccessesBeforeUp = updated.SuccessesBeforeUp
+	existing.ReminderInterval = updated.ReminderInterval
+
+	if existing.ConnectionType == database.ConnectionTypePush && existing.PushToken == "" {
+		token, err := generatePushToken()
+		if err != nil {
+			m.mu.Unlock()
+			return err
+		}
+		existing.PushToken = token
+	}
 
 	m.mu.Unlock()
 
-	err := m.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Omit("Notification").Save(existing).Error; err != nil {
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Notifications").Save(existing).Error; err != nil {
 			return err
 		}
 
-		if updated.Notification.Type != "" || updated.Notification.Webhook != "" {
-			notification := database.Notification{
-				MonitorID: existing.ID,
-				Enabled:   updated.Notification.Enabled,
-				Type:      updated.Notification.Type,
-				Webhook:   updated.Notification.Webhook,
-				Email:     updated.Notification.Email,
-				Template:  updated.Notification.Template,
-			}
-
-			return tx.Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "monitor_id"}},
-				DoUpdates: clause.AssignmentColumns([]string{"enabled", "type", "webhook", "email", "error_title", "error_body", "success_title", "success_body"}),
-			}).Create(&notification).Error
-		} else {
-			return tx.Where("monitor_id = ?", existing.ID).Delete(&database.Notification{}).Error
+		if err := tx.Model(existing).Association("HttpMonitorHeaders").Unscoped().Replace(existing.HttpMonitorHeaders); err != nil {
+			return err
+		}
+		if err := tx.Model(existing).Association("HttpMonitorAssertions").Unscoped().Replace(existing.HttpMonitorAssertions); err != nil {
+			return err
 		}
+
+		return saveLinks(tx, existing.ID, links)
 	})
 
 	if err != nil {
 		return fmt.Errorf("failed to update monitor %d: %w", updated.ID, err)
 	}
 
+	m.mu.Lock()
+	if err := preloadMonitor(m.db).First(existing, existing.ID).Error; err != nil {
+		log.Error("failed to reload associations for monitor %d: %v", existing.ID, err)
+	}
+	m.mu.Unlock()
+
+	m.publishMonitor(EventMonitorUpdated, existing)
 	m.startMonitor(int(existing.ID))
 
 	log.Info("monitor updated: %s (ID: %d)", existing.Name, existing.ID)
 
-	if err := m.db.Preload(clause.Associations).First(existing, existing.ID).Error; err != nil {
-		log.Error("failed to reload associations for monitor %d: %v", existing.ID, err)
-	}
-
 	return nil
 }
 
-func (m *Manager) GetMonitor(id int) *database.Monitor {
+// GetMonitor loads a monitor from the database, nil is returned when it does
+// not exist or is not visible with the given access.
+func (m *Manager) GetMonitor(id int, access Access) *database.Monitor {
 	var mon database.Monitor
-	if err := m.db.Preload(clause.Associations).Where("id = ?", id).Find(&mon).Error; err != nil {
+	if err := preloadMonitor(m.db).Where("id = ?", id).Find(&mon).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil
 		}
@@ -193,9 +350,11 @@ func (m *Manager) GetMonitor(id int) *database.Monitor {
 		return nil
 	}
 
-	if mon.ID == 0 {
+	if mon.ID == 0 || !access.CanSee(&mon) {
 		return nil
 	}
+
+	mon.InMaintenance = m.activeMaintenance(&mon, time.Now()) != nil
 	return &mon
 }
 
@@ -214,7 +373,7 @@ func (m *Manager) RunMonitor(id int) (*database.Monitor, error) {
 
 	m.runCheck(context.Background(), id)
 
-	updated := m.GetMonitor(id)
+	updated := m.GetMonitor(id, FullAccess)
 	if updated == nil {
 		return nil, fmt.Errorf("failed to reload monitor %d after manual run", id)
 	}
@@ -222,40 +381,168 @@ func (m *Manager) RunMonitor(id int) (*database.Monitor, error) {
 	return updated, nil
 }
 
-func (m *Manager) ListMonitors() map[int]*database.Monitor {
+// RecordPush handles a ping sent by a job to the push URL of its monitor.
+func (m *Manager) RecordPush(token string, ping PushPing) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	id := 0
+	for candidateID, candidate := range m.monitors {
+		if candidate.ConnectionType == database.ConnectionTypePush && candidate.PushToken == token {
+			id = candidateID
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	if id == 0 {
+		return ErrUnknownPushToken
+	}
+
+	unlock := m.lockChecks(id)
+	defer unlock()
+
+	m.mu.Lock()
+	mon := m.monitors[id]
+	if mon == nil {
+		m.mu.Unlock()
+		return ErrUnknownPushToken
+	}
+	if !mon.Enabled {
+		m.mu.Unlock()
+		return fmt.Errorf("monitor %d is disabled", mon.ID)
+	}
+
+	now := time.Now()
 
-	copy := make(map[int]*database.Monitor, len(m.monitors))
-	maps.Copy(copy, m.monitors)
-	return copy
+	if ping.Kind == PushStart {
+		mon.PushStartedAt = &now
+		err := m.db.Save(mon).Error
+		m.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to record start of monitor %d: %w", mon.ID, err)
+		}
+		return nil
+	}
+
+	var responseTime int64
+	switch {
+	case ping.DurationMs != nil:
+		responseTime = *ping.DurationMs
+	case mon.PushStartedAt != nil:
+		responseTime = now.Sub(*mon.PushStartedAt).Milliseconds()
+	}
+
+	mon.PushLastPing = now
+	mon.PushStartedAt = nil
+	m.mu.Unlock()
+
+	var err error
+	if ping.Kind == PushFail {
+		err = errors.New("job reported failure")
+	}
+
+	m.recordResult(mon, now, ping.Message, responseTime, err, nil)
+	return nil
 }
 
-func (m *Manager) RemoveMonitor(id int) error {
+func (m *Manager) ListMonitors(access Access) map[int]*database.Monitor {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	var (
+		now     = time.Now()
+		visible = make(map[int]*database.Monitor, len(m.monitors))
+	)
+
+	for id, mon := range m.monitors {
+		if !access.CanSee(mon) {
+			continue
+		}
+		mon.InMaintenance = m.findMaintenance(mon, now) != nil
+		visible[id] = mon
+	}
+
+	return visible
+}
+
+// SetMonitorEnabled pauses or resumes the checks of a monitor.
+func (m *Manager) SetMonitorEnabled(id int, enabled bool) error {
+	m.mu.Lock()
 	mon, exists := m.monitors[id]
+	m.mu.Unlock()
 	if !exists {
 		return fmt.Errorf("monitor %d does not exist", id)
 	}
 
-	if runner, ok := m.runners[id]; ok {
-		runner.cancel()
-		<-runner.done
-		delete(m.runners, id)
+	m.stopRunner(id)
+
+	m.mu.Lock()
+	mon.Enabled = enabled
+	err := m.db.Model(mon).Update("enabled", enabled).Error
+	m.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to update monitor %d: %w", id, err)
+	}
+
+	log.Info("monitor enabled set to %t: %s (ID: %d)", enabled, mon.Name, id)
+	m.publishMonitor(EventMonitorUpdated, mon)
+
+	// The runner resets the state of paused monitors
+	m.startMonitor(id)
+	return nil
+}
+
+// validateKey rejects keys used by another monitor, the caller holds mu.
+func (m *Manager) validateKey(id uint, key string) error {
+	if key == "" {
+		return nil
+	}
+	for _, existing := range m.monitors {
+		if existing.ID != id && existing.Key == key {
+			return fmt.Errorf("%w: key %q is already used by monitor %q", ErrInvalidMonitor, key, existing.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) RemoveMonitor(id int) error {
+	m.mu.Lock()
+	mon, exists := m.monitors[id]
+	m.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("monitor %d does not exist", id)
 	}
 
+	m.stopRunner(id)
+	m.forget(uint(id))
+
+	m.mu.Lock()
 	delete(m.monitors, id)
+	delete(m.checkLocks, id)
+	m.removeParent(uint(id))
+	m.mu.Unlock()
 
 	if err := m.db.Delete(mon).Error; err != nil {
 		return fmt.Errorf("failed to delete monitor %d from database: %w", id, err)
 	}
 
 	log.Info("monitor removed: %s (ID: %d)", mon.Name, id)
+	m.publishMonitor(EventMonitorDeleted, mon)
 	return nil
 }
 
+// forget drops the state handlers keep for the monitor.
+func (m *Manager) forget(id uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, handler := range m.handlers {
+		if forgetter, ok := handler.(Forgetter); ok {
+			forgetter.Forget(id)
+		}
+	}
+}
+
 func (m *Manager) startMonitor(monID int) {
 	ctx, cancel := context.WithCancel(m.ctx)
 	done := make(chan struct{})
@@ -271,29 +558,68 @@ func (m *Manager) startMonitor(monID int) {
 
 		m.runCheck(ctx, monID)
 
-		m.mu.Lock()
-		mon := m.monitors[monID]
-		m.mu.Unlock()
-
-		if mon == nil {
-			return
-		}
-
-		ticker := time.NewTicker(time.Duration(mon.Interval) * time.Second)
-		defer ticker.Stop()
+		timer := time.NewTimer(m.nextCheckDelay(monID))
+		defer timer.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
+			case <-timer.C:
 				m.runCheck(ctx, monID)
+				timer.Reset(m.nextCheckDelay(monID))
 			}
 		}
 	}()
 }
 
+func (m *Manager) nextCheckDelay(monID int) time.Duration {
+	unlock := m.lockChecks(monID)
+	defer unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mon := m.monitors[monID]
+	if mon == nil {
+		return time.Minute
+	}
+	return nextCheckDelay(mon)
+}
+
+// lockChecks locks the check state of a monitor and returns the function
+// unlocking it. It must be called without holding m.mu.
+func (m *Manager) lockChecks(monID int) func() {
+	m.mu.Lock()
+	lock, ok := m.checkLocks[monID]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.checkLocks[monID] = lock
+	}
+	m.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// stopRunner cancels the check loop of a monitor and waits for it to exit.
+// It must be called without holding m.mu, as a running check acquires it.
+func (m *Manager) stopRunner(monID int) {
+	m.mu.Lock()
+	runner, ok := m.runners[monID]
+	delete(m.runners, monID)
+	m.mu.Unlock()
+
+	if ok {
+		runner.cancel()
+		<-runner.done
+	}
+}
+
 func (m *Manager) runCheck(ctx context.Context, monID int) {
+	unlock := m.lockChecks(monID)
+	defer unlock()
+
 	m.mu.Lock()
 	mon := m.monitors[monID]
 	if mon == nil {
@@ -303,6 +629,9 @@ func (m *Manager) runCheck(ctx context.Context, monID int) {
 
 	if !mon.Enabled {
 		mon.Healthy = nil
+		mon.Status = database.StatusUnknown
+		mon.ConsecutiveFailures = 0
+		mon.ConsecutiveSuccesses = 0
 		if err := m.db.Save(mon).Error; err != nil {
 			log.Error("failed to update disabled monitor %d: %v", mon.ID, err)
 		}
@@ -310,7 +639,6 @@ func (m *Manager) runCheck(ctx context.Context, monID int) {
 		return
 	}
 
-	wasUnhealthy := mon.Healthy != nil && !*mon.Healthy
 	handler, handlerExists := m.handlers[database.ConnectionType(mon.ConnectionType)]
 	m.mu.Unlock()
 
@@ -319,81 +647,143 @@ func (m *Manager) runCheck(ctx context.Context, monID int) {
 		return
 	}
 
+	window := m.activeMaintenance(mon, time.Now())
+	mon.InMaintenance = window != nil
+	if window != nil && window.Mode == database.MaintenanceSkip {
+		log.Debug("skipping check of monitor %d during maintenance '%s'", mon.ID, window.Name)
+		return
+	}
+
+	checkCtx, details := withCheckDetails(ctx)
+	start := time.Now()
+	response, responseTime, err := handler.Check(checkCtx, mon)
+	if errors.Is(err, ErrSkipCheck) {
+		return
+	}
+
+	m.recordResult(mon, start, response, responseTime, err, details)
+}
+
+// recordResult stores the outcome of a check, updates the monitor state and
+// sends notifications when the monitor goes down, recovers or stays down for
+// longer than its reminder interval.
+func (m *Manager) recordResult(mon *database.Monitor, start time.Time, response string, responseTime int64, err error, details *CheckDetails) {
+	if details != nil && details.CertExpiresAt != nil {
+		m.mu.Lock()
+		mon.CertExpiresAt = details.CertExpiresAt
+		m.mu.Unlock()
+	}
+
 	var (
-		start                       = time.Now()
-		response, responseTime, err = handler.Check(ctx, mon)
-		result                      = response
-		healthy                     = err == nil
-		notificationSent            = false
+		result           = response
+		degraded         = errors.Is(err, ErrDegraded)
+		healthy          = err == nil || degraded
+		notificationSent = false
+		previous         = mon.Status
 	)
 
+	defer func() {
+		if mon.Status != previous {
+			m.publishStateChange(mon, previous, start)
+		}
+	}()
+
 	if err != nil && result == "" {
 		result = err.Error()
-	} else if healthy && !mon.AlwaysSave {
+	} else if healthy && !degraded && !mon.AlwaysSave {
 		result = ""
 	}
 
-	if err != nil && mon.Notification.Enabled {
-		notifier := notification.NewWebhookNotifier(mon.Notification.Webhook)
-
-		msg := notification.Message{
-			Level:     notification.Error,
-			Timestamp: time.Now(),
-			Template: &notification.MessageTemplate{
-				Title: mon.Notification.Template.ErrorTitle,
-				Body:  mon.Notification.Template.ErrorBody,
-			},
-			TemplateData: &notification.TemplateData{
-				Name:       mon.Name,
-				Timestamp:  time.Now().Format(time.RFC3339),
-				Connection: mon.Connection,
-				Error:      result,
-				Level:      string(notification.Error),
-			},
-		}
+	if m.activeMaintenance(mon, start) != nil {
+		// Checks during maintenance are kept for reference, but do not change
+		// the monitor state, send notifications or count towards uptime
+		mon.Checked = start
+		m.saveCheck(mon, &database.MonitorCheck{
+			MonitorID:      mon.ID,
+			Created:        start,
+			Success:        healthy,
+			Result:         result,
+			ResponseTimeMs: responseTime,
+			Maintenance:    true,
+		})
+		return
+	}
 
-		if err := msg.RenderTemplate(); err != nil {
-			msg.Title = fmt.Sprintf("Issues with %s", mon.Name)
-			msg.Text = fmt.Sprintf("The goose has encountered an issue while contacting %s\n\n```\n%s\n```", mon.Connection, result)
+	if !healthy {
+		if root := m.downDependency(mon); root != nil {
+			// The failure is most likely caused by the parent, which already
+			// sent a notification listing this monitor as affected
+			mon.Status = database.StatusUnreachable
+			mon.Checked = start
+			mon.TotalChecks++
+			m.saveCheck(mon, &database.MonitorCheck{
+				MonitorID:      mon.ID,
+				Created:        start,
+				Success:        false,
+				Result:         fmt.Sprintf("Unreachable, dependency %s is down\n\n%s", root.Name, result),
+				ResponseTimeMs: responseTime,
+			})
+			return
 		}
+	}
 
-		notifier.Send(msg)
-		notificationSent = true
+	transition := applyResult(mon, healthy, start)
+	switch transition {
+	case wentDown:
+		m.openIncident(mon, start, result)
+		notificationSent = m.notify(mon, notification.EventDown, result, start)
+	case recovered:
+		m.resolveIncident(mon, start)
+		notificationSent = m.notify(mon, notification.EventUp, result, start)
+		mon.DownSince = nil
+	default:
+		if reminderDue(mon, start) {
+			mon.RemindersSent++
+			mon.LastReminder = &start
+			notificationSent = m.notify(mon, notification.EventReminder, result, start)
+		}
 	}
 
-	if wasUnhealthy && healthy && mon.Notification.Enabled {
-		notifier := notification.NewWebhookNotifier(mon.Notification.Webhook)
-		notifier.Send(notification.Message{
-			Title: fmt.Sprintf("%s is back up", mon.Name),
-			Text:  fmt.Sprintf("Good news! The monitor **%s** has recovered and is now responding normally.\n\nConnection: %s", mon.Name, mon.Connection),
-			Level: notification.Success,
-		})
-		notificationSent = true
+	// Degraded is only notified once when it starts, recovering from it is
+	// not announced separately
+	wasDegraded := mon.Degraded
+	mon.Degraded = degraded && mon.Status == database.StatusUp
+	if mon.Degraded && !wasDegraded {
+		notificationSent = m.notify(mon, notification.EventDegraded, result, start) || notificationSent
 	}
 
 	mon.Checked = start
-	mon.Healthy = &healthy
 	mon.TotalChecks++
 	if healthy {
 		mon.SuccessfulChecks++
 	}
 
-	check := &database.MonitorCheck{
+	m.saveCheck(mon, &database.MonitorCheck{
 		MonitorID:        mon.ID,
 		Created:          start,
 		Success:          healthy,
 		Result:           result,
 		ResponseTimeMs:   responseTime,
 		NotificationSent: notificationSent,
-	}
+	})
+}
+
+func (m *Manager) saveCheck(mon *database.Monitor, check *database.MonitorCheck) {
+	mon.ResponseTimeMs = check.ResponseTimeMs
 
 	if err := m.db.Create(check).Error; err != nil {
 		log.Error("failed to save check for monitor %d: %v", mon.ID, err)
 	}
 
-	if err := m.db.Save(mon).Error; err != nil {
+	// Saving writes back fields read by others under mu
+	m.mu.Lock()
+	err := m.db.Save(mon).Error
+	m.mu.Unlock()
+	if err != nil {
 		log.Error("failed to update monitor %d after check: %v", mon.ID, err)
 	}
+
+	m.publishCheck(mon, check)
 }
 
 func (m *Manager) Stop() {
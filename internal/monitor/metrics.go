@@ -0,0 +1,146 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"honk/internal/database"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+type notificationCounts struct {
+	Sent   uint64
+	Failed uint64
+}
+
+type metricSample struct {
+	labels string
+	value  float64
+}
+
+type metric struct {
+	name    string
+	help    string
+	kind    string
+	samples []metricSample
+}
+
+func (m *Manager) countNotification(id uint, sent bool) {
+	m.notificationsMu.Lock()
+	defer m.notificationsMu.Unlock()
+
+	counts, ok := m.notifications[id]
+	if !ok {
+		counts = &notificationCounts{}
+		m.notifications[id] = counts
+	}
+
+	if sent {
+		counts.Sent++
+	} else {
+		counts.Failed++
+	}
+}
+
+// WriteMetrics writes the state of all visible monitors in the Prometheus text
+// exposition format.
+func (m *Manager) WriteMetrics(w io.Writer, access Access) error {
+	var (
+		now           = time.Now()
+		up            = metric{name: "honk_monitor_up", help: "Whether the monitor is up (1) or down (0).", kind: "gauge"}
+		status        = metric{name: "honk_monitor_status", help: "Current status of the monitor, 1 for the active status.", kind: "gauge"}
+		responseTime  = metric{name: "honk_monitor_response_time_ms", help: "Response time of the last check in milliseconds.", kind: "gauge"}
+		checks        = metric{name: "honk_monitor_checks_total", help: "Number of checks by result.", kind: "counter"}
+		certExpiry    = metric{name: "honk_monitor_cert_expiry_seconds", help: "Seconds until the certificate of the monitor expires.", kind: "gauge"}
+		notifications = metric{name: "honk_monitor_notifications_total", help: "Number of notifications sent since startup by result.", kind: "counter"}
+	)
+
+	m.mu.Lock()
+	monitors := make([]*database.Monitor, 0, len(m.monitors))
+	for _, mon := range m.monitors {
+		if access.CanSee(mon) {
+			monitors = append(monitors, mon)
+		}
+	}
+	slices.SortFunc(monitors, func(a, b *database.Monitor) int { return int(a.ID) - int(b.ID) })
+
+	for _, mon := range monitors {
+		labels := monitorLabels(mon)
+
+		if mon.Healthy != nil {
+			up.samples = append(up.samples, metricSample{labels, boolValue(*mon.Healthy)})
+		}
+
+		for _, s := range []database.MonitorStatus{database.StatusUp, database.StatusDown, database.StatusPending, database.StatusUnreachable} {
+			status.samples = append(status.samples, metricSample{
+				labels: labels + fmt.Sprintf(`,status="%s"`, s),
+				value:  boolValue(mon.Status == s),
+			})
+		}
+
+		if !mon.Checked.IsZero() {
+			responseTime.samples = append(responseTime.samples, metricSample{labels, float64(mon.ResponseTimeMs)})
+		}
+
+		checks.samples = append(checks.samples,
+			metricSample{labels + `,result="success"`, float64(mon.SuccessfulChecks)},
+			metricSample{labels + `,result="failure"`, float64(mon.TotalChecks - mon.SuccessfulChecks)},
+		)
+
+		if mon.CertExpiresAt != nil {
+			certExpiry.samples = append(certExpiry.samples, metricSample{labels, mon.CertExpiresAt.Sub(now).Seconds()})
+		}
+	}
+	m.mu.Unlock()
+
+	m.notificationsMu.Lock()
+	for _, mon := range monitors {
+		counts, ok := m.notifications[mon.ID]
+		if !ok {
+			continue
+		}
+
+		labels := monitorLabels(mon)
+		notifications.samples = append(notifications.samples,
+			metricSample{labels + `,result="success"`, float64(counts.Sent)},
+			metricSample{labels + `,result="failure"`, float64(counts.Failed)},
+		)
+	}
+	m.notificationsMu.Unlock()
+
+	buf := bufio.NewWriter(w)
+	for _, metric := range []metric{up, status, responseTime, checks, certExpiry, notifications} {
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for _, sample := range metric.samples {
+			fmt.Fprintf(buf, "%s{%s} %g\n", metric.name, sample.labels, sample.value)
+		}
+	}
+
+	return buf.Flush()
+}
+
+// monitorLabels returns the labels identifying a monitor, tags are joined
+// with commas as Prometheus labels cannot hold lists.
+func monitorLabels(mon *database.Monitor) string {
+	return fmt.Sprintf(`id="%d",name="%s",type="%s",tags="%s"`,
+		mon.ID,
+		escapeLabel(mon.Name),
+		escapeLabel(string(mon.ConnectionType)),
+		escapeLabel(strings.Join(mon.Tags, ",")),
+	)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,156 @@
+package monitor
+
+import (
+	"fmt"
+	"honk/internal/database"
+	"honk/internal/notification"
+	"slices"
+	"strings"
+	"time"
+)
+
+// notify sends a notification about a state change of the monitor to every
+// linked channel subscribed to the event and reports whether one was sent.
+func (m *Manager) notify(mon *database.Monitor, event notification.Event, result string, now time.Time) bool {
+	var links []database.MonitorNotification
+	if err := m.db.Preload("Notification").Where("monitor_id = ?", mon.ID).Find(&links).Error; err != nil {
+		log.Error("failed to load notification channels of monitor %d: %v", mon.ID, err)
+		return false
+	}
+
+	sent := false
+	for _, link := range links {
+		channel := link.Notification
+		if !channel.Enabled || !slices.Contains(link.Events, database.NotificationEvent(event)) {
+			continue
+		}
+
+		notifier, err := m.Notifier(&channel)
+		if err != nil {
+			log.Error("cannot notify %s about monitor %d: %v", channel.Name, mon.ID, err)
+			m.countNotification(mon.ID, false)
+			continue
+		}
+
+		msg := m.buildMessage(mon, &channel, event, result, now)
+		if err := notifier.Send(msg); err != nil {
+			log.Error("failed to send %s notification for monitor %d to %s: %v", event, mon.ID, channel.Name, err)
+			m.countNotification(mon.ID, false)
+			continue
+		}
+
+		m.countNotification(mon.ID, true)
+		sent = true
+	}
+
+	return sent
+}
+
+// SetSMTP sets the mail server used by email channels.
+func (m *Manager) SetSMTP(settings notification.SMTPSettings) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.smtp = settings
+}
+
+// Notifier returns the sender delivering to the platform of the channel.
+func (m *Manager) Notifier(channel *database.Notification) (notification.Sender, error) {
+	switch platform := notification.Platform(channel.Type); platform {
+	case notification.Email:
+		recipients, err := notification.ParseRecipients(channel.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		m.mu.Lock()
+		settings := m.smtp
+		m.mu.Unlock()
+		return notification.NewEmailNotifier(settings, recipients)
+	case notification.Webhook:
+		return notification.NewGenericWebhookNotifier(channel.Webhook, notification.WebhookOptions(channel.WebhookOptions))
+	default:
+		return notification.NewWebhookNotifier(platform, channel.Webhook)
+	}
+}
+
+// buildMessage renders the message for the event with the templates of the
+// channel.
+func (m *Manager) buildMessage(mon *database.Monitor, channel *database.Notification, event notification.Event, result string, now time.Time) notification.Message {
+	var downtime time.Duration
+	if mon.DownSince != nil {
+		downtime = now.Sub(*mon.DownSince).Round(time.Second)
+	}
+
+	msg := notification.Message{
+		Timestamp: now,
+		TemplateData: &notification.TemplateData{
+			Name:       mon.Name,
+			Timestamp:  now.Format(time.RFC3339),
+			Connection: mon.Connection,
+			Error:      result,
+			Downtime:   downtime.String(),
+			Reminder:   mon.RemindersSent,
+		},
+	}
+
+	switch event {
+	case notification.EventDown, notification.EventReminder:
+		msg.Level = notification.Error
+		msg.Title = fmt.Sprintf("Issues with %s", mon.Name)
+		msg.Text = fmt.Sprintf("The goose has encountered an issue while contacting %s\n\n```\n%s\n```", mon.Connection, result)
+		msg.Template = &notification.MessageTemplate{
+			Title: channel.Template.ErrorTitle,
+			Body:  channel.Template.ErrorBody,
+		}
+	case notification.EventDegraded:
+		msg.Level = notification.Warning
+		msg.Title = fmt.Sprintf("%s is degraded", mon.Name)
+		msg.Text = fmt.Sprintf("The goose noticed a warning while contacting %s\n\n```\n%s\n```", mon.Connection, result)
+		msg.Template = &notification.MessageTemplate{
+			Title: channel.Template.ErrorTitle,
+			Body:  channel.Template.ErrorBody,
+		}
+	case notification.EventUp:
+		msg.Level = notification.Success
+		msg.Title = fmt.Sprintf("%s is back up", mon.Name)
+		msg.Text = fmt.Sprintf("Good news! The monitor **%s** has recovered and is now responding normally.\n\nConnection: %s", mon.Name, mon.Connection)
+		msg.Template = &notification.MessageTemplate{
+			Title: channel.Template.SuccessTitle,
+			Body:  channel.Template.SuccessBody,
+		}
+	}
+	msg.TemplateData.Level = string(msg.Level)
+
+	// Render here so a broken template falls back to the default text
+	// instead of failing the send
+	if err := msg.RenderTemplate(); err != nil {
+		log.Warning("failed to render notification template for monitor %d: %v", mon.ID, err)
+	}
+	msg.Template = nil
+
+	if event == notification.EventDown {
+		// Channels of a team only learn the names of its own monitors
+		dependents, hidden := m.dependentNames(mon.ID, audience(channel))
+		switch {
+		case hidden == 1:
+			dependents = append(dependents, "1 monitor of another team")
+		case hidden > 1:
+			dependents = append(dependents, fmt.Sprintf("%d monitors of other teams", hidden))
+		}
+		if len(dependents) > 0 {
+			msg.Text += fmt.Sprintf("\n\nAlso affected (dependency down): %s", strings.Join(dependents, ", "))
+		}
+	}
+
+	switch event {
+	case notification.EventReminder:
+		msg.Title = fmt.Sprintf("Reminder #%d: %s", mon.RemindersSent, msg.Title)
+		msg.Text += fmt.Sprintf("\n\nStill down after %s.", downtime)
+	case notification.EventUp:
+		if downtime > 0 {
+			msg.Text += fmt.Sprintf("\n\nDowntime: %s", downtime)
+		}
+	}
+
+	return msg
+}
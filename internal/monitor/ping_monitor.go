@@ -1,69 +1,299 @@
 package monitor
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"honk/internal/database"
-	"os/exec"
-	"runtime"
+	"net"
+	"os"
+	"slices"
+	"strings"
 	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	DEFAULT_PING_COUNT    = 3
+	DEFAULT_PING_INTERVAL = 200 * time.Millisecond
+
+	protocolICMP   = 1
+	protocolICMPv6 = 58
 )
 
 type ICMPPingHandler struct {
-	Timeout time.Duration
+	Timeout  time.Duration
+	Count    int
+	Interval time.Duration
 }
 
 func NewICMPPingHandler(timeout time.Duration) *ICMPPingHandler {
 	return &ICMPPingHandler{
-		Timeout: timeout,
+		Timeout:  timeout,
+		Count:    DEFAULT_PING_COUNT,
+		Interval: DEFAULT_PING_INTERVAL,
+	}
+}
+
+// pingStats holds the round trip times of the replies received during a check.
+type pingStats struct {
+	Sent int
+	RTTs []time.Duration
+}
+
+func (s pingStats) Loss() float64 {
+	if s.Sent == 0 {
+		return 0
+	}
+	return float64(s.Sent-len(s.RTTs)) / float64(s.Sent) * 100
+}
+
+func (s pingStats) Avg() time.Duration {
+	if len(s.RTTs) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, rtt := range s.RTTs {
+		total += rtt
+	}
+	return total / time.Duration(len(s.RTTs))
+}
+
+// Jitter is the mean difference between consecutive round trip times.
+func (s pingStats) Jitter() time.Duration {
+	if len(s.RTTs) < 2 {
+		return 0
+	}
+
+	var total time.Duration
+	for i := 1; i < len(s.RTTs); i++ {
+		diff := s.RTTs[i] - s.RTTs[i-1]
+		total += max(diff, -diff)
+	}
+	return total / time.Duration(len(s.RTTs)-1)
+}
+
+func (s pingStats) String() string {
+	summary := fmt.Sprintf("%d packets transmitted, %d received, %.1f%% packet loss", s.Sent, len(s.RTTs), s.Loss())
+	if len(s.RTTs) == 0 {
+		return summary
 	}
+
+	return fmt.Sprintf("%s\nrtt min/avg/max/jitter = %.2f/%.2f/%.2f/%.2f ms",
+		summary,
+		milliseconds(slices.Min(s.RTTs)),
+		milliseconds(s.Avg()),
+		milliseconds(slices.Max(s.RTTs)),
+		milliseconds(s.Jitter()),
+	)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
 
 func (h *ICMPPingHandler) Check(ctx context.Context, m *database.Monitor) (string, int64, error) {
 	host := m.Connection
 
-	var cmd *exec.Cmd
-
-	start := time.Now()
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.CommandContext(
-			ctx,
-			"ping",
-			"-c", "1",
-			"-W", fmt.Sprintf("%d", h.Timeout.Milliseconds()),
-			host,
-		)
-	default:
-		timeoutSeconds := int(h.Timeout.Seconds())
-		if timeoutSeconds < 1 {
-			timeoutSeconds = 1
+	count := m.PingCount
+	if count <= 0 {
+		count = h.Count
+	}
+
+	timeout := h.Timeout
+	if m.Timeout > 0 {
+		timeout = time.Duration(m.Timeout) * time.Second
+	}
+
+	threshold := float64(m.PingLossThreshold)
+	if threshold <= 0 {
+		threshold = 100
+	}
+
+	ip, err := resolvePingTarget(ctx, host)
+	if err != nil {
+		return fmt.Sprintf("Failed to resolve %s: %v", host, err), 0, err
+	}
+
+	conn, privileged, err := listenICMP(ip)
+	if err != nil {
+		return fmt.Sprintf("Failed to open ICMP socket: %v", err), 0, err
+	}
+	defer func() {
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Warning("failed to close icmp socket: %v", closeErr)
 		}
+	}()
+
+	log.Debug("ICMP handler '%s' pinging %s (%s) with %d packets", m.Name, host, ip, count)
 
-		cmd = exec.CommandContext(
-			ctx,
-			"ping",
-			"-c", "1",
-			"-W", fmt.Sprintf("%d", timeoutSeconds),
-			host,
-		)
+	stats, err := h.ping(ctx, conn, ip, privileged, count, timeout)
+	if err != nil {
+		return fmt.Sprintf("Ping to %s failed: %v", host, err), 0, err
 	}
-	duration := time.Since(start).Milliseconds()
 
-	log.Debug("ICMP handler '%s' pinging %s", m.Name, host)
+	var (
+		avgMs  = stats.Avg().Milliseconds()
+		result = fmt.Sprintf("Ping to %s (%s)\n\n%s", host, ip, stats)
+	)
 
-	output, err := cmd.CombinedOutput()
+	if stats.Loss() >= threshold {
+		return result, avgMs, fmt.Errorf("packet loss %.1f%% reached threshold of %.0f%%", stats.Loss(), threshold)
+	}
+
+	return result, avgMs, nil
+}
+
+func resolvePingTarget(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
 	if err != nil {
-		return fmt.Sprintf(
-			"Ping to %s failed\n\n%s",
-			host,
-			string(output),
-		), duration, err
-	}
-
-	return fmt.Sprintf(
-		"Ping to %s successful\n\n%s",
-		host,
-		string(output),
-	), duration, nil
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+
+	// Prefer IPv4 as IPv6 connectivity is often missing in containers
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			return addr.IP, nil
+		}
+	}
+	return addrs[0].IP, nil
+}
+
+// listenICMP opens an unprivileged datagram socket, falling back to a raw
+// socket when the kernel does not allow them (net.ipv4.ping_group_range).
+func listenICMP(ip net.IP) (*icmp.PacketConn, bool, error) {
+	datagram, raw, address := "udp4", "ip4:icmp", "0.0.0.0"
+	if ip.To4() == nil {
+		datagram, raw, address = "udp6", "ip6:ipv6-icmp", "::"
+	}
+
+	conn, err := icmp.ListenPacket(datagram, address)
+	if err == nil {
+		return conn, false, nil
+	}
+
+	conn, rawErr := icmp.ListenPacket(raw, address)
+	if rawErr != nil {
+		return nil, false, errors.Join(err, rawErr)
+	}
+	return conn, true, nil
+}
+
+func (h *ICMPPingHandler) ping(ctx context.Context, conn *icmp.PacketConn, ip net.IP, privileged bool, count int, timeout time.Duration) (pingStats, error) {
+	var (
+		stats     pingStats
+		id        = os.Getpid() & 0xffff
+		isIPv4    = ip.To4() != nil
+		requestTy icmp.Type
+		replyTy   icmp.Type
+		protocol  int
+		dst       net.Addr
+	)
+
+	if isIPv4 {
+		requestTy, replyTy, protocol = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply, protocolICMP
+	} else {
+		requestTy, replyTy, protocol = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply, protocolICMPv6
+	}
+
+	if privileged {
+		dst = &net.IPAddr{IP: ip}
+	} else {
+		dst = &net.UDPAddr{IP: ip}
+	}
+
+	// The kernel rewrites the ID of unprivileged echo requests, replies are
+	// matched on sequence number and a random payload instead
+	payload := make([]byte, 16)
+	if _, err := rand.Read(payload); err != nil {
+		return stats, err
+	}
+
+	buf := make([]byte, 1500)
+
+	for seq := range count {
+		if seq > 0 {
+			select {
+			case <-ctx.Done():
+				return stats, ctx.Err()
+			case <-time.After(h.Interval):
+			}
+		}
+
+		request, err := (&icmp.Message{
+			Type: requestTy,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: payload},
+		}).Marshal(nil)
+		if err != nil {
+			return stats, err
+		}
+
+		sent := time.Now()
+		if _, err := conn.WriteTo(request, dst); err != nil {
+			return stats, err
+		}
+		stats.Sent++
+
+		deadline := sent.Add(timeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return stats, err
+		}
+
+		for {
+			n, peer, err := conn.ReadFrom(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				return stats, err
+			}
+
+			if !samePeer(peer, ip) {
+				continue
+			}
+
+			reply, err := icmp.ParseMessage(protocol, buf[:n])
+			if err != nil || reply.Type != replyTy {
+				continue
+			}
+
+			echo, ok := reply.Body.(*icmp.Echo)
+			if !ok || echo.Seq != seq || !bytes.Equal(echo.Data, payload) || (privileged && echo.ID != id) {
+				continue
+			}
+
+			stats.RTTs = append(stats.RTTs, time.Since(sent))
+			break
+		}
+	}
+
+	return stats, nil
+}
+
+func samePeer(peer net.Addr, ip net.IP) bool {
+	switch addr := peer.(type) {
+	case *net.UDPAddr:
+		return addr.IP.Equal(ip)
+	case *net.IPAddr:
+		return addr.IP.Equal(ip)
+	default:
+		return strings.HasPrefix(peer.String(), ip.String())
+	}
 }
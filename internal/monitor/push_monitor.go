@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"honk/internal/database"
+	"time"
+)
+
+const (
+	pushPollInterval = 30 * time.Second
+)
+
+var ErrUnknownPushToken = errors.New("unknown push token")
+
+type PushKind string
+
+const (
+	PushSuccess PushKind = "success"
+	PushFail    PushKind = "fail"
+	PushStart   PushKind = "start"
+)
+
+// PushPing is a heartbeat reported by a job through its push URL.
+// DurationMs is optional, without it the time since the start ping is used.
+type PushPing struct {
+	Kind       PushKind
+	DurationMs *int64
+	Message    string
+}
+
+// PushHandler checks that push monitors received a ping in time. The pings
+// themselves are recorded through Manager.RecordPush.
+type PushHandler struct{}
+
+func NewPushHandler() *PushHandler {
+	return &PushHandler{}
+}
+
+func (h *PushHandler) Check(ctx context.Context, m *database.Monitor) (string, int64, error) {
+	last := m.PushLastPing
+	if last.IsZero() {
+		last = m.CreatedAt
+	}
+	if last.IsZero() {
+		return "", 0, ErrSkipCheck
+	}
+
+	var (
+		now      = time.Now()
+		grace    = time.Duration(m.GracePeriod) * time.Second
+		deadline = last.Add(time.Duration(m.Interval)*time.Second + grace)
+	)
+
+	// Nothing changes while within the deadline or when already marked down
+	if now.Before(deadline) || (m.Healthy != nil && !*m.Healthy) {
+		return "", 0, ErrSkipCheck
+	}
+
+	if m.PushStartedAt != nil {
+		msg := fmt.Sprintf("Job started at %s but has not finished after %s", m.PushStartedAt.Format(time.RFC3339), now.Sub(*m.PushStartedAt).Round(time.Second))
+		return msg, 0, errors.New("push job did not finish")
+	}
+
+	msg := fmt.Sprintf("No ping received since %s (expected every %ds with %ds grace period)", last.Format(time.RFC3339), m.Interval, m.GracePeriod)
+	return msg, 0, errors.New("push deadline exceeded")
+}
+
+func generatePushToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate push token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,73 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"honk/internal/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/honk.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	m := NewManager(db)
+	t.Cleanup(m.Stop)
+	return m
+}
+
+// Run with -race, pings from jobs arrive while the runner checks the monitor.
+func TestRecordPushDuringChecks(t *testing.T) {
+	m := newTestManager(t)
+	m.RegisterHandler(database.ConnectionTypePush, NewPushHandler())
+
+	mon, err := m.AddMonitor(&database.Monitor{
+		Name:           "job",
+		ConnectionType: database.ConnectionTypePush,
+		Enabled:        true,
+		Interval:       60,
+	})
+	if err != nil {
+		t.Fatalf("failed to add monitor: %v", err)
+	}
+
+	token := mon.PushToken
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			kind := PushSuccess
+			if i%2 == 0 {
+				kind = PushFail
+			}
+			if err := m.RecordPush(token, PushPing{Kind: kind}); err != nil {
+				t.Errorf("failed to record push: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			m.runCheck(context.Background(), int(mon.ID))
+			m.nextCheckDelay(int(mon.ID))
+		}()
+	}
+	wg.Wait()
+
+	// Checks within the deadline are skipped, only the pings are recorded
+	got := m.GetMonitor(int(mon.ID), FullAccess)
+	if got.TotalChecks != 20 || got.SuccessfulChecks != 10 {
+		t.Errorf("expected 20 checks with 10 successful, got %d with %d", got.TotalChecks, got.SuccessfulChecks)
+	}
+}
@@ -0,0 +1,286 @@
+package monitor
+
+import (
+	"fmt"
+	"honk/internal/database"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DEFAULT_RAW_RETENTION_DAYS    = 30
+	DEFAULT_HOURLY_RETENTION_DAYS = 180
+	DEFAULT_DAILY_RETENTION_DAYS  = 0
+
+	retentionInterval = time.Hour
+)
+
+// RetentionPolicy controls how long check history is kept. Checks are rolled up
+// into hourly aggregates once their hour is over, raw checks are deleted after
+// RawDays. Hourly aggregates older than HourlyDays are merged into daily ones
+// and daily aggregates are deleted after DailyDays. A value of zero keeps that
+// level forever.
+type RetentionPolicy struct {
+	RawDays    int `json:"rawDays"`
+	HourlyDays int `json:"hourlyDays"`
+	DailyDays  int `json:"dailyDays"`
+}
+
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		RawDays:    DEFAULT_RAW_RETENTION_DAYS,
+		HourlyDays: DEFAULT_HOURLY_RETENTION_DAYS,
+		DailyDays:  DEFAULT_DAILY_RETENTION_DAYS,
+	}
+}
+
+// SetRetention replaces the retention policy, it is applied on the next run.
+func (m *Manager) SetRetention(policy RetentionPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retention = policy
+}
+
+func (m *Manager) runRetention() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		m.applyRetention(time.Now())
+
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) applyRetention(now time.Time) {
+	m.mu.Lock()
+	policy := m.retention
+	m.mu.Unlock()
+
+	if err := m.rollupChecks(now.Truncate(time.Hour)); err != nil {
+		log.Error("failed to roll up checks: %v", err)
+		return
+	}
+
+	// Expired checks are already covered by the aggregates of their hours
+	if policy.RawDays > 0 {
+		err := m.db.
+			Where("created < ?", startOfDay(now.AddDate(0, 0, -policy.RawDays))).
+			Delete(&database.MonitorCheck{}).Error
+		if err != nil {
+			log.Error("failed to prune expired checks: %v", err)
+		}
+	}
+
+	if policy.HourlyDays > 0 {
+		if err := m.rollupHourly(startOfDay(now.AddDate(0, 0, -policy.HourlyDays))); err != nil {
+			log.Error("failed to roll up expired hourly aggregates: %v", err)
+		}
+	}
+
+	if policy.DailyDays > 0 {
+		err := m.db.
+			Where("resolution = ? AND bucket_start < ?", database.RollupDaily, startOfDay(now.AddDate(0, 0, -policy.DailyDays))).
+			Delete(&database.MonitorCheckRollup{}).Error
+		if err != nil {
+			log.Error("failed to prune expired daily aggregates: %v", err)
+		}
+	}
+}
+
+// rollupChecks aggregates the checks of the hours before end that are not
+// rolled up yet, one monitor day at a time so memory use stays bounded.
+func (m *Manager) rollupChecks(end time.Time) error {
+	m.mu.Lock()
+	monitorIDs := make([]uint, 0, len(m.monitors))
+	for _, mon := range m.monitors {
+		monitorIDs = append(monitorIDs, mon.ID)
+	}
+	m.mu.Unlock()
+
+	for _, id := range monitorIDs {
+		start, err := m.rolledUpUntil(id)
+		if err != nil {
+			return err
+		}
+
+		for {
+			if m.ctx.Err() != nil {
+				return nil
+			}
+
+			var oldest database.MonitorCheck
+			err := m.db.Select("created").
+				Where("monitor_id = ? AND created >= ? AND created < ?", id, start, end).
+				Order("created").
+				Limit(1).
+				Find(&oldest).Error
+			if err != nil {
+				return err
+			}
+			if oldest.Created.IsZero() {
+				break
+			}
+
+			var (
+				hourStart = oldest.Created.Truncate(time.Hour)
+				dayEnd    = startOfDay(oldest.Created).AddDate(0, 0, 1)
+			)
+			if dayEnd.After(end) {
+				dayEnd = end
+			}
+
+			if err := m.rollupCheckHours(id, hourStart, dayEnd); err != nil {
+				return fmt.Errorf("monitor %d on %s: %w", id, hourStart.Format(time.DateOnly), err)
+			}
+			start = dayEnd
+		}
+	}
+
+	return nil
+}
+
+// rolledUpUntil returns the end of the latest aggregate of the monitor, later
+// checks are not rolled up yet.
+func (m *Manager) rolledUpUntil(monitorID uint) (time.Time, error) {
+	var latest database.MonitorCheckRollup
+	err := m.db.Select("resolution", "bucket_start").
+		Where("monitor_id = ?", monitorID).
+		Order("bucket_start DESC").
+		Limit(1).
+		Find(&latest).Error
+	if err != nil || latest.BucketStart.IsZero() {
+		return time.Time{}, err
+	}
+
+	if latest.Resolution == database.RollupDaily {
+		return latest.BucketStart.AddDate(0, 0, 1), nil
+	}
+	return latest.BucketStart.Add(time.Hour), nil
+}
+
+// rollupCheckHours creates the hourly aggregates of the checks between start
+// and end. Hours with only checks during maintenance still get an empty
+// aggregate, so they are not rolled up again.
+func (m *Manager) rollupCheckHours(monitorID uint, start, end time.Time) error {
+	var checks []database.MonitorCheck
+	err := m.db.Select("created", "success", "response_time_ms", "maintenance").
+		Where("monitor_id = ? AND created >= ? AND created < ?", monitorID, start, end).
+		Order("created").
+		Find(&checks).Error
+	if err != nil {
+		return err
+	}
+
+	var (
+		rollups []database.MonitorCheckRollup
+		bucket  time.Time
+		times   []int64
+	)
+
+	for _, check := range checks {
+		hour := check.Created.Truncate(time.Hour)
+		if len(rollups) == 0 || !hour.Equal(bucket) {
+			if len(rollups) > 0 {
+				setLatency(&rollups[len(rollups)-1], times)
+			}
+			bucket, times = hour, times[:0]
+			rollups = append(rollups, database.MonitorCheckRollup{
+				MonitorID:   monitorID,
+				Resolution:  database.RollupHourly,
+				BucketStart: hour,
+			})
+		}
+		if check.Maintenance {
+			continue
+		}
+
+		rollup := &rollups[len(rollups)-1]
+		rollup.Count++
+		if check.Success {
+			rollup.Successes++
+			times = append(times, check.ResponseTimeMs)
+		}
+	}
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	setLatency(&rollups[len(rollups)-1], times)
+	return m.db.Create(&rollups).Error
+}
+
+// rollupHourly merges the hourly aggregates before cutoff into daily ones. The
+// daily p95 is the highest hourly p95, an upper bound of the real value.
+func (m *Manager) rollupHourly(cutoff time.Time) error {
+	var hourly []database.MonitorCheckRollup
+	err := m.db.
+		Where("resolution = ? AND bucket_start < ?", database.RollupHourly, cutoff).
+		Order("monitor_id, bucket_start").
+		Find(&hourly).Error
+	if err != nil || len(hourly) == 0 {
+		return err
+	}
+
+	var daily []database.MonitorCheckRollup
+	for _, h := range hourly {
+		day := startOfDay(h.BucketStart)
+
+		last := len(daily) - 1
+		if last < 0 || daily[last].MonitorID != h.MonitorID || !daily[last].BucketStart.Equal(day) {
+			daily = append(daily, database.MonitorCheckRollup{
+				MonitorID:   h.MonitorID,
+				Resolution:  database.RollupDaily,
+				BucketStart: day,
+				MinMs:       h.MinMs,
+			})
+			last++
+		}
+
+		d := &daily[last]
+		if h.Successes > 0 {
+			if d.Successes == 0 || h.MinMs < d.MinMs {
+				d.MinMs = h.MinMs
+			}
+			d.AvgMs = (d.AvgMs*float64(d.Successes) + h.AvgMs*float64(h.Successes)) / float64(d.Successes+h.Successes)
+		}
+		d.Count += h.Count
+		d.Successes += h.Successes
+		d.MaxMs = max(d.MaxMs, h.MaxMs)
+		d.P95Ms = max(d.P95Ms, h.P95Ms)
+	}
+
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&daily).Error; err != nil {
+			return err
+		}
+		return tx.Where("resolution = ? AND bucket_start < ?", database.RollupHourly, cutoff).
+			Delete(&database.MonitorCheckRollup{}).Error
+	})
+}
+
+func setLatency(rollup *database.MonitorCheckRollup, times []int64) {
+	if len(times) == 0 {
+		return
+	}
+
+	sorted := slices.Sorted(slices.Values(times))
+	latency := responseTimeStats(sorted)
+
+	rollup.MinMs = sorted[0]
+	rollup.MaxMs = sorted[len(sorted)-1]
+	rollup.AvgMs = latency.Mean
+	rollup.P95Ms = latency.P95
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
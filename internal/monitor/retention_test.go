@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"honk/internal/database"
+)
+
+func TestApplyRetentionBoundaries(t *testing.T) {
+	m := newTestManager(t)
+	m.RegisterHandler(database.ConnectionTypePush, NewPushHandler())
+	m.SetRetention(RetentionPolicy{RawDays: 1, HourlyDays: 2, DailyDays: 3})
+
+	mon, err := m.AddMonitor(&database.Monitor{
+		Name:           "api",
+		ConnectionType: database.ConnectionTypePush,
+		Interval:       60,
+	})
+	if err != nil {
+		t.Fatalf("failed to add monitor: %v", err)
+	}
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.Local)
+	}
+	now := at(10, 12, 30)
+
+	var checks []database.MonitorCheck
+	for _, created := range []time.Time{
+		at(10, 12, 10), // current hour, not rolled up yet
+		at(10, 11, 10), // last complete hour, rolled up and kept
+		at(9, 0, 0),    // first raw check still kept
+		at(8, 23, 59),  // expired raw check, only its hour remains
+		at(8, 0, 5),    // first hour still kept hourly
+		at(7, 10, 0),   // hours merged into a daily aggregate
+		at(7, 11, 0),
+		at(6, 10, 0), // expired daily aggregate
+	} {
+		checks = append(checks, database.MonitorCheck{MonitorID: mon.ID, Created: created, Success: true, ResponseTimeMs: 100})
+	}
+	if err := m.db.Create(&checks).Error; err != nil {
+		t.Fatalf("failed to save checks: %v", err)
+	}
+
+	// Running again must not aggregate the same checks twice
+	m.applyRetention(now)
+	m.applyRetention(now)
+
+	var raw []database.MonitorCheck
+	m.db.Order("created").Find(&raw)
+	expectedRaw := []time.Time{at(9, 0, 0), at(10, 11, 10), at(10, 12, 10)}
+	if len(raw) != len(expectedRaw) {
+		t.Fatalf("expected %d raw checks, got %d", len(expectedRaw), len(raw))
+	}
+	for i, check := range raw {
+		if !check.Created.Equal(expectedRaw[i]) {
+			t.Errorf("raw check %d: expected %s, got %s", i, expectedRaw[i], check.Created)
+		}
+	}
+
+	var rollups []database.MonitorCheckRollup
+	m.db.Order("bucket_start").Find(&rollups)
+	expected := []struct {
+		resolution database.RollupResolution
+		start      time.Time
+		count      int64
+	}{
+		{database.RollupDaily, at(7, 0, 0), 2},
+		{database.RollupHourly, at(8, 0, 0), 1},
+		{database.RollupHourly, at(8, 23, 0), 1},
+		{database.RollupHourly, at(9, 0, 0), 1},
+		{database.RollupHourly, at(10, 11, 0), 1},
+	}
+	if len(rollups) != len(expected) {
+		t.Fatalf("expected %d aggregates, got %d", len(expected), len(rollups))
+	}
+	for i, rollup := range rollups {
+		want := expected[i]
+		if rollup.Resolution != want.resolution || !rollup.BucketStart.Equal(want.start) || rollup.Count != want.count {
+			t.Errorf("aggregate %d: expected %d %s checks at %s, got %d %s checks at %s", i,
+				want.count, want.resolution, want.start, rollup.Count, rollup.Resolution, rollup.BucketStart)
+		}
+	}
+}
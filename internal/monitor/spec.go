@@ -0,0 +1,400 @@
+package monitor
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"honk/internal/database"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+type SpecFormat string
+
+const (
+	FormatYAML SpecFormat = "yaml"
+	FormatJSON SpecFormat = "json"
+)
+
+// MonitorsDocument is the declarative form of a set of monitors, used to
+// import, export and sync monitors kept in version control.
+type MonitorsDocument struct {
+	Monitors []MonitorSpec `json:"monitors"`
+}
+
+// MonitorSpec holds the configuration of a monitor without its runtime state.
+// Monitors are matched by Key and reference their parents by key.
+type MonitorSpec struct {
+	Key            string                  `json:"key"`
+	Name           string                  `json:"name"`
+	Enabled        *bool                   `json:"enabled,omitempty"` // defaults to true
+	ConnectionType database.ConnectionType `json:"type"`
+	Connection     string                  `json:"connection,omitempty"` // empty for push monitors
+	Interval       int                     `json:"interval"`
+	Timeout        int                     `json:"timeout,omitempty"`
+	AlwaysSave     bool                    `json:"alwaysSave,omitempty"`
+	Tags           []string                `json:"tags,omitempty"`
+	Parents        []string                `json:"parents,omitempty"`
+
+	HTTPMethod  string            `json:"httpMethod,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	BodyType    database.BodyType `json:"bodyType,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	Headers     []HeaderSpec      `json:"headers,omitempty"`
+	Assertions  []AssertionSpec   `json:"assertions,omitempty"`
+
+	RetriesBeforeDown int `json:"retriesBeforeDown,omitempty"`
+	RetryInterval     int `json:"retryInterval,omitempty"`
+	SuccessesBeforeUp int `json:"successesBeforeUp,omitempty"`
+	ReminderInterval  int `json:"reminderInterval,omitempty"`
+
+	CertExpiryDays     int  `json:"certExpiryDays,omitempty"`
+	CertExpiryWarnOnly bool `json:"certExpiryWarnOnly,omitempty"`
+
+	DNSRecordType string                `json:"dnsRecordType,omitempty"`
+	DNSResolver   string                `json:"dnsResolver,omitempty"`
+	DNSMatch      database.DNSMatchMode `json:"dnsMatch,omitempty"`
+	DNSExpected   string                `json:"dnsExpected,omitempty"`
+	DNSMinTTL     int                   `json:"dnsMinTTL,omitempty"`
+	DNSMaxTTL     int                   `json:"dnsMaxTTL,omitempty"`
+
+	ContainerFailOnUnhealthy bool `json:"containerFailOnUnhealthy,omitempty"`
+	ContainerMaxRestarts     int  `json:"containerMaxRestarts,omitempty"`
+	ContainerRestartWindow   int  `json:"containerRestartWindow,omitempty"`
+
+	PingCount         int `json:"pingCount,omitempty"`
+	PingLossThreshold int `json:"pingLossThreshold,omitempty"`
+	GracePeriod       int `json:"gracePeriod,omitempty"`
+
+	Notifications []NotificationLinkSpec `json:"notifications,omitempty"`
+}
+
+type HeaderSpec struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type AssertionSpec struct {
+	Type     database.AssertionType     `json:"type"`
+	Target   string                     `json:"target,omitempty"`
+	Operator database.AssertionOperator `json:"operator,omitempty"`
+	Value    string                     `json:"value,omitempty"`
+}
+
+// NotificationLinkSpec links a monitor to a notification channel by name,
+// without events the default events are sent.
+type NotificationLinkSpec struct {
+	Channel string                       `json:"channel"`
+	Events  []database.NotificationEvent `json:"events,omitempty"`
+}
+
+var (
+	validKey     = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+	keyInvalidCh = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// ParseMonitors decodes and validates a document in the given format.
+func ParseMonitors(data []byte, format SpecFormat) (*MonitorsDocument, error) {
+	var doc MonitorsDocument
+
+	switch format {
+	case FormatYAML:
+		if err := yaml.UnmarshalWithOptions(data, &doc, yaml.Strict()); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMonitor, yaml.FormatError(err, false, true))
+		}
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMonitor, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	keys := make(map[string]bool, len(doc.Monitors))
+	for i := range doc.Monitors {
+		spec := &doc.Monitors[i]
+		if !validKey.MatchString(spec.Key) {
+			return nil, fmt.Errorf("%w: monitor %d has an invalid key %q, use lowercase letters, digits, '.', '_' or '-'", ErrInvalidMonitor, i+1, spec.Key)
+		}
+		if keys[spec.Key] {
+			return nil, fmt.Errorf("%w: duplicate key %q", ErrInvalidMonitor, spec.Key)
+		}
+		keys[spec.Key] = true
+
+		if spec.ConnectionType == "" {
+			return nil, fmt.Errorf("%w: monitor %q has no type", ErrInvalidMonitor, spec.Key)
+		}
+		if spec.Connection == "" && spec.ConnectionType != database.ConnectionTypePush {
+			return nil, fmt.Errorf("%w: monitor %q has no connection", ErrInvalidMonitor, spec.Key)
+		}
+		if spec.Interval <= 0 {
+			return nil, fmt.Errorf("%w: monitor %q needs a positive interval", ErrInvalidMonitor, spec.Key)
+		}
+		if spec.Name == "" {
+			spec.Name = spec.Key
+		}
+		spec.normalize()
+	}
+
+	return &doc, nil
+}
+
+func MarshalMonitors(doc *MonitorsDocument, format SpecFormat) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		return yaml.Marshal(doc)
+	case FormatJSON:
+		return json.MarshalIndent(doc, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// ExportMonitors returns the visible monitors as a document. Monitors without
+// a key get one derived from their name, so they can be imported elsewhere.
+func (m *Manager) ExportMonitors(access Access) (*MonitorsDocument, error) {
+	channels, err := m.channelNames()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	monitors := make([]*database.Monitor, 0, len(m.monitors))
+	for _, mon := range m.monitors {
+		if access.CanSee(mon) {
+			monitors = append(monitors, mon)
+		}
+	}
+	slices.SortFunc(monitors, func(a, b *database.Monitor) int { return int(a.ID) - int(b.ID) })
+
+	keys := m.exportKeys()
+	doc := &MonitorsDocument{Monitors: make([]MonitorSpec, 0, len(monitors))}
+	for _, mon := range monitors {
+		doc.Monitors = append(doc.Monitors, toSpec(mon, keys, channels))
+	}
+	return doc, nil
+}
+
+// channelNames maps the ids of all notification channels to their names.
+func (m *Manager) channelNames() (map[uint]string, error) {
+	channels, err := m.ListChannels(FullAccess)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification channels: %w", err)
+	}
+
+	names := make(map[uint]string, len(channels))
+	for _, channel := range channels {
+		names[channel.ID] = channel.Name
+	}
+	return names, nil
+}
+
+// channelLinks resolves the channels of a spec by name.
+func (m *Manager) channelLinks(specs []NotificationLinkSpec) ([]database.MonitorNotification, error) {
+	names, err := m.channelNames()
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]database.MonitorNotification, 0, len(specs))
+	for _, spec := range specs {
+		id, ok := findKey(names, spec.Channel)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrChannelNotFound, spec.Channel)
+		}
+		links = append(links, database.MonitorNotification{NotificationID: id, Events: spec.Events})
+	}
+	return links, nil
+}
+
+func findKey(m map[uint]string, value string) (uint, bool) {
+	for k, v := range m {
+		if v == value {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// exportKeys maps all monitor ids to their key, the caller holds mu.
+func (m *Manager) exportKeys() map[uint]string {
+	keys := make(map[uint]string, len(m.monitors))
+	used := make(map[string]bool, len(m.monitors))
+	for _, mon := range m.monitors {
+		if mon.Key != "" {
+			keys[mon.ID] = mon.Key
+			used[mon.Key] = true
+		}
+	}
+
+	for _, mon := range m.monitors {
+		if mon.Key != "" {
+			continue
+		}
+		key := strings.Trim(keyInvalidCh.ReplaceAllString(strings.ToLower(mon.Name), "-"), "-")
+		if key == "" || used[key] {
+			key = fmt.Sprintf("%s-%d", cmp.Or(key, "monitor"), mon.ID)
+		}
+		keys[mon.ID] = key
+		used[key] = true
+	}
+	return keys
+}
+
+func toSpec(mon *database.Monitor, keys, channels map[uint]string) MonitorSpec {
+	enabled := mon.Enabled
+	spec := MonitorSpec{
+		Key:                      keys[mon.ID],
+		Name:                     mon.Name,
+		Enabled:                  &enabled,
+		ConnectionType:           mon.ConnectionType,
+		Connection:               mon.Connection,
+		Interval:                 mon.Interval,
+		Timeout:                  mon.Timeout,
+		AlwaysSave:               mon.AlwaysSave,
+		Tags:                     mon.Tags,
+		HTTPMethod:               mon.HTTPMethod,
+		Body:                     mon.Body,
+		BodyType:                 mon.BodyType,
+		ContentType:              mon.ContentType,
+		RetriesBeforeDown:        mon.RetriesBeforeDown,
+		RetryInterval:            mon.RetryInterval,
+		SuccessesBeforeUp:        mon.SuccessesBeforeUp,
+		ReminderInterval:         mon.ReminderInterval,
+		CertExpiryDays:           mon.CertExpiryDays,
+		CertExpiryWarnOnly:       mon.CertExpiryWarnOnly,
+		DNSRecordType:            mon.DNSRecordType,
+		DNSResolver:              mon.DNSResolver,
+		DNSMatch:                 mon.DNSMatch,
+		DNSExpected:              mon.DNSExpected,
+		DNSMinTTL:                mon.DNSMinTTL,
+		DNSMaxTTL:                mon.DNSMaxTTL,
+		ContainerFailOnUnhealthy: mon.ContainerFailOnUnhealthy,
+		ContainerMaxRestarts:     mon.ContainerMaxRestarts,
+		ContainerRestartWindow:   mon.ContainerRestartWindow,
+		PingCount:                mon.PingCount,
+		PingLossThreshold:        mon.PingLossThreshold,
+		GracePeriod:              mon.GracePeriod,
+	}
+
+	// The connection of push monitors is their secret token
+	if mon.ConnectionType == database.ConnectionTypePush {
+		spec.Connection = ""
+	}
+
+	for _, parent := range mon.ParentIDs {
+		spec.Parents = append(spec.Parents, keys[parent])
+	}
+	for _, header := range mon.HttpMonitorHeaders {
+		spec.Headers = append(spec.Headers, HeaderSpec{Key: header.Key, Value: header.Value})
+	}
+	for _, assertion := range mon.HttpMonitorAssertions {
+		spec.Assertions = append(spec.Assertions, AssertionSpec{
+			Type:     assertion.Type,
+			Target:   assertion.Target,
+			Operator: assertion.Operator,
+			Value:    assertion.Value,
+		})
+	}
+
+	for _, link := range mon.Notifications {
+		spec.Notifications = append(spec.Notifications, NotificationLinkSpec{
+			Channel: channels[link.NotificationID],
+			Events:  link.Events,
+		})
+	}
+
+	spec.normalize()
+	return spec
+}
+
+// toMonitor builds the monitor described by the spec, parents and channels
+// are resolved by the caller.
+func (spec MonitorSpec) toMonitor(parentIDs []uint, links []database.MonitorNotification) *database.Monitor {
+	mon := &database.Monitor{
+		Key:                      spec.Key,
+		Name:                     spec.Name,
+		Enabled:                  *spec.Enabled,
+		ConnectionType:           spec.ConnectionType,
+		Connection:               spec.Connection,
+		Interval:                 spec.Interval,
+		Timeout:                  spec.Timeout,
+		AlwaysSave:               spec.AlwaysSave,
+		Tags:                     spec.Tags,
+		ParentIDs:                parentIDs,
+		Notifications:            links,
+		HTTPMethod:               spec.HTTPMethod,
+		Body:                     spec.Body,
+		BodyType:                 spec.BodyType,
+		ContentType:              spec.ContentType,
+		RetriesBeforeDown:        spec.RetriesBeforeDown,
+		RetryInterval:            spec.RetryInterval,
+		SuccessesBeforeUp:        spec.SuccessesBeforeUp,
+		ReminderInterval:         spec.ReminderInterval,
+		CertExpiryDays:           spec.CertExpiryDays,
+		CertExpiryWarnOnly:       spec.CertExpiryWarnOnly,
+		DNSRecordType:            spec.DNSRecordType,
+		DNSResolver:              spec.DNSResolver,
+		DNSMatch:                 spec.DNSMatch,
+		DNSExpected:              spec.DNSExpected,
+		DNSMinTTL:                spec.DNSMinTTL,
+		DNSMaxTTL:                spec.DNSMaxTTL,
+		ContainerFailOnUnhealthy: spec.ContainerFailOnUnhealthy,
+		ContainerMaxRestarts:     spec.ContainerMaxRestarts,
+		ContainerRestartWindow:   spec.ContainerRestartWindow,
+		PingCount:                spec.PingCount,
+		PingLossThreshold:        spec.PingLossThreshold,
+		GracePeriod:              spec.GracePeriod,
+	}
+
+	for _, header := range spec.Headers {
+		mon.HttpMonitorHeaders = append(mon.HttpMonitorHeaders, database.HttpMonitorHeader{Key: header.Key, Value: header.Value})
+	}
+	for _, assertion := range spec.Assertions {
+		mon.HttpMonitorAssertions = append(mon.HttpMonitorAssertions, database.HttpMonitorAssertion{
+			Type:     assertion.Type,
+			Target:   assertion.Target,
+			Operator: assertion.Operator,
+			Value:    assertion.Value,
+		})
+	}
+
+	return mon
+}
+
+// normalize fills in defaults and drops empty lists, so specs from files and
+// from the database compare equal.
+func (spec *MonitorSpec) normalize() {
+	if spec.Enabled == nil {
+		enabled := true
+		spec.Enabled = &enabled
+	}
+	if len(spec.Tags) == 0 {
+		spec.Tags = nil
+	}
+	if len(spec.Parents) == 0 {
+		spec.Parents = nil
+	}
+	if len(spec.Headers) == 0 {
+		spec.Headers = nil
+	}
+	if len(spec.Assertions) == 0 {
+		spec.Assertions = nil
+	}
+	if len(spec.Notifications) == 0 {
+		spec.Notifications = nil
+	}
+	for i := range spec.Notifications {
+		if len(spec.Notifications[i].Events) == 0 {
+			spec.Notifications[i].Events = slices.Clone(database.DefaultNotificationEvents)
+		}
+	}
+}
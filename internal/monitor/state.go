@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"honk/internal/database"
+	"time"
+)
+
+type transition int
+
+const (
+	noTransition transition = iota
+	wentDown
+	recovered
+)
+
+// applyResult updates the confirmed state of a monitor with the outcome of a
+// check. A failing monitor is only marked down after RetriesBeforeDown extra
+// failures and a down monitor only recovers after SuccessesBeforeUp successes,
+// in between the monitor is pending.
+func applyResult(mon *database.Monitor, healthy bool, now time.Time) transition {
+	if healthy {
+		mon.ConsecutiveSuccesses++
+		mon.ConsecutiveFailures = 0
+	} else {
+		mon.ConsecutiveFailures++
+		mon.ConsecutiveSuccesses = 0
+	}
+
+	confirmedDown := mon.Healthy != nil && !*mon.Healthy
+
+	switch {
+	case healthy && !confirmedDown:
+		mon.Healthy = &healthy
+		mon.Status = database.StatusUp
+		return noTransition
+
+	case healthy && mon.ConsecutiveSuccesses >= max(1, mon.SuccessesBeforeUp):
+		mon.Healthy = &healthy
+		mon.Status = database.StatusUp
+		return recovered
+
+	case !healthy && confirmedDown:
+		mon.Status = database.StatusDown
+		if mon.DownSince == nil {
+			mon.DownSince = &now
+		}
+		return noTransition
+
+	case !healthy && mon.ConsecutiveFailures > mon.RetriesBeforeDown:
+		mon.Healthy = &healthy
+		mon.Status = database.StatusDown
+		mon.DownSince = &now
+		mon.RemindersSent = 0
+		mon.LastReminder = nil
+		return wentDown
+	}
+
+	mon.Status = database.StatusPending
+	return noTransition
+}
+
+// reminderDue reports whether a monitor that is still down should send another
+// notification, one is sent every ReminderInterval seconds of downtime.
+func reminderDue(mon *database.Monitor, now time.Time) bool {
+	if mon.Status != database.StatusDown || mon.ReminderInterval <= 0 || mon.DownSince == nil {
+		return false
+	}
+
+	last := *mon.DownSince
+	if mon.LastReminder != nil {
+		last = *mon.LastReminder
+	}
+
+	return now.Sub(last) >= time.Duration(mon.ReminderInterval)*time.Second
+}
+
+// nextCheckDelay returns how long to wait before checking the monitor again,
+// pending monitors are re-checked after the retry interval when one is set.
+func nextCheckDelay(mon *database.Monitor) time.Duration {
+	interval := time.Duration(mon.Interval) * time.Second
+
+	if mon.Status == database.StatusPending && mon.RetryInterval > 0 {
+		interval = time.Duration(mon.RetryInterval) * time.Second
+	}
+
+	if mon.ConnectionType == database.ConnectionTypePush {
+		// Push monitors only compare timestamps, poll often so missed
+		// deadlines are noticed quickly even for long intervals
+		interval = min(interval, pushPollInterval)
+	}
+
+	return interval
+}
@@ -0,0 +1,194 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"honk/internal/database"
+)
+
+func TestApplyResult(t *testing.T) {
+	var (
+		up   = true
+		down = false
+	)
+
+	tests := []struct {
+		name        string
+		monitor     database.Monitor
+		results     []bool
+		transitions []transition
+		status      database.MonitorStatus
+	}{
+		{
+			name:        "first success is up",
+			results:     []bool{true},
+			transitions: []transition{noTransition},
+			status:      database.StatusUp,
+		},
+		{
+			name:        "first failure is down without retries",
+			results:     []bool{false},
+			transitions: []transition{wentDown},
+			status:      database.StatusDown,
+		},
+		{
+			name:        "failures stay pending until the retries are used",
+			monitor:     database.Monitor{Healthy: &up, RetriesBeforeDown: 2},
+			results:     []bool{false, false, false},
+			transitions: []transition{noTransition, noTransition, wentDown},
+			status:      database.StatusDown,
+		},
+		{
+			name:        "success while pending resets the retries",
+			monitor:     database.Monitor{Healthy: &up, RetriesBeforeDown: 1},
+			results:     []bool{false, true, false, false},
+			transitions: []transition{noTransition, noTransition, noTransition, wentDown},
+			status:      database.StatusDown,
+		},
+		{
+			name:        "down stays down without notifying again",
+			monitor:     database.Monitor{Healthy: &up},
+			results:     []bool{false, false},
+			transitions: []transition{wentDown, noTransition},
+			status:      database.StatusDown,
+		},
+		{
+			name:        "one success recovers by default",
+			monitor:     database.Monitor{Healthy: &down, Status: database.StatusDown},
+			results:     []bool{true},
+			transitions: []transition{recovered},
+			status:      database.StatusUp,
+		},
+		{
+			name:        "recovery waits for the successes",
+			monitor:     database.Monitor{Healthy: &down, Status: database.StatusDown, SuccessesBeforeUp: 3},
+			results:     []bool{true, true, true},
+			transitions: []transition{noTransition, noTransition, recovered},
+			status:      database.StatusUp,
+		},
+		{
+			name:        "failure while recovering returns to down",
+			monitor:     database.Monitor{Healthy: &down, Status: database.StatusDown, SuccessesBeforeUp: 2},
+			results:     []bool{true, false, true, true},
+			transitions: []transition{noTransition, noTransition, noTransition, recovered},
+			status:      database.StatusUp,
+		},
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mon := tt.monitor
+			for i, healthy := range tt.results {
+				if got := applyResult(&mon, healthy, now.Add(time.Duration(i)*time.Minute)); got != tt.transitions[i] {
+					t.Errorf("result %d: expected transition %d, got %d", i, tt.transitions[i], got)
+				}
+			}
+			if mon.Status != tt.status {
+				t.Errorf("expected status %q, got %q", tt.status, mon.Status)
+			}
+		})
+	}
+}
+
+func TestApplyResultStatuses(t *testing.T) {
+	var (
+		up  = true
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		mon = database.Monitor{Healthy: &up, RetriesBeforeDown: 1, SuccessesBeforeUp: 2}
+	)
+
+	steps := []struct {
+		healthy bool
+		status  database.MonitorStatus
+	}{
+		{false, database.StatusPending},
+		{false, database.StatusDown},
+		{true, database.StatusPending},
+		{true, database.StatusUp},
+	}
+
+	for i, step := range steps {
+		applyResult(&mon, step.healthy, now.Add(time.Duration(i)*time.Minute))
+		if mon.Status != step.status {
+			t.Fatalf("step %d: expected status %q, got %q", i, step.status, mon.Status)
+		}
+	}
+
+	// DownSince is kept until the runner resolves the incident
+	if mon.DownSince == nil || !mon.DownSince.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected the monitor down since the confirming failure, got %v", mon.DownSince)
+	}
+}
+
+func TestReminderDue(t *testing.T) {
+	var (
+		downSince = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		reminded  = downSince.Add(10 * time.Minute)
+	)
+
+	tests := []struct {
+		name    string
+		monitor database.Monitor
+		now     time.Time
+		want    bool
+	}{
+		{"disabled", database.Monitor{Status: database.StatusDown, DownSince: &downSince}, downSince.Add(time.Hour), false},
+		{"not down", database.Monitor{Status: database.StatusPending, DownSince: &downSince, ReminderInterval: 60}, downSince.Add(time.Hour), false},
+		{"too early", database.Monitor{Status: database.StatusDown, DownSince: &downSince, ReminderInterval: 600}, downSince.Add(5 * time.Minute), false},
+		{"after the interval", database.Monitor{Status: database.StatusDown, DownSince: &downSince, ReminderInterval: 600}, downSince.Add(10 * time.Minute), true},
+		{"counted from the last reminder", database.Monitor{Status: database.StatusDown, DownSince: &downSince, LastReminder: &reminded, ReminderInterval: 600}, downSince.Add(15 * time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reminderDue(&tt.monitor, tt.now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNextCheckDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		monitor database.Monitor
+		want    time.Duration
+	}{
+		{"interval", database.Monitor{Interval: 60, Status: database.StatusUp}, time.Minute},
+		{"pending uses the retry interval", database.Monitor{Interval: 60, RetryInterval: 10, Status: database.StatusPending}, 10 * time.Second},
+		{"pending without retry interval", database.Monitor{Interval: 60, Status: database.StatusPending}, time.Minute},
+		{"push monitors poll", database.Monitor{Interval: 3600, ConnectionType: database.ConnectionTypePush}, pushPollInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextCheckDelay(&tt.monitor); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDownDependency(t *testing.T) {
+	m := newTestManager(t)
+	m.monitors = map[int]*database.Monitor{
+		1: {ID: 1, Name: "router", Status: database.StatusDown},
+		2: {ID: 2, Name: "switch", Status: database.StatusUnreachable, ParentIDs: []uint{1}},
+		3: {ID: 3, Name: "server", Status: database.StatusUp, ParentIDs: []uint{2}},
+		4: {ID: 4, Name: "backup", Status: database.StatusUp},
+	}
+
+	if root := m.downDependency(m.monitors[3]); root == nil || root.ID != 1 {
+		t.Errorf("expected the unreachable parent to lead to the router, got %v", root)
+	}
+	if root := m.downDependency(m.monitors[4]); root != nil {
+		t.Errorf("expected no down dependency without parents, got %s", root.Name)
+	}
+
+	m.monitors[1].Status = database.StatusPending
+	if root := m.downDependency(m.monitors[3]); root != nil {
+		t.Errorf("pending parents are not confirmed down, got %s", root.Name)
+	}
+}
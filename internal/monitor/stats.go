@@ -0,0 +1,327 @@
+package monitor
+
+import (
+	"cmp"
+	"fmt"
+	"honk/internal/database"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stats are cached for a short time so dashboards polling several ranges do
+// not query the aggregates on every request.
+const statsCacheTTL = time.Minute
+
+type ResponseTimeStats struct {
+	Mean float64 `json:"meanMs"`
+	P50  int64   `json:"p50Ms"`
+	P95  int64   `json:"p95Ms"`
+	P99  int64   `json:"p99Ms"`
+}
+
+// Stats summarizes checks and incidents within a time range. Checks recorded
+// during maintenance are ignored, MTTR and MTBF are given in seconds.
+type Stats struct {
+	From             time.Time         `json:"from"`
+	To               time.Time         `json:"to"`
+	Uptime           *float64          `json:"uptime"` // nil without checks
+	Checks           int64             `json:"checks"`
+	SuccessfulChecks int64             `json:"successfulChecks"`
+	ResponseTime     ResponseTimeStats `json:"responseTime"`
+	Incidents        int               `json:"incidents"`
+	Downtime         float64           `json:"downtime"`
+	MTTR             float64           `json:"mttr"`
+	MTBF             float64           `json:"mtbf"`
+}
+
+type statsKey struct {
+	monitorID uint
+	access    string
+	from, to  int64
+}
+
+type cachedStats struct {
+	stats   Stats
+	expires time.Time
+}
+
+// MonitorStats returns the statistics of a single monitor between from and to.
+func (m *Manager) MonitorStats(id uint, from, to time.Time) (*Stats, error) {
+	m.mu.Lock()
+	mon, ok := m.monitors[int(id)]
+	var createdAt time.Time
+	if ok {
+		createdAt = mon.CreatedAt
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("monitor %d does not exist", id)
+	}
+
+	return m.cachedStats(statsKey{monitorID: id}, from, to, func() (Stats, error) {
+		return m.computeStats([]uint{id}, map[uint]time.Time{id: createdAt}, from, to)
+	})
+}
+
+// GlobalStats returns the statistics of all visible monitors combined between
+// from and to.
+func (m *Manager) GlobalStats(from, to time.Time, access Access) (*Stats, error) {
+	m.mu.Lock()
+	created := make(map[uint]time.Time, len(m.monitors))
+	for _, mon := range m.monitors {
+		if access.CanSee(mon) {
+			created[mon.ID] = mon.CreatedAt
+		}
+	}
+	m.mu.Unlock()
+
+	ids := slices.Sorted(maps.Keys(created))
+
+	return m.cachedStats(statsKey{access: access.key()}, from, to, func() (Stats, error) {
+		return m.computeStats(ids, created, from, to)
+	})
+}
+
+func (m *Manager) cachedStats(key statsKey, from, to time.Time, compute func() (Stats, error)) (*Stats, error) {
+	now := time.Now()
+	key.from = from.Truncate(statsCacheTTL).Unix()
+	key.to = to.Truncate(statsCacheTTL).Unix()
+
+	m.statsMu.Lock()
+	cached, ok := m.statsCache[key]
+	m.statsMu.Unlock()
+
+	if ok && now.Before(cached.expires) {
+		return &cached.stats, nil
+	}
+
+	stats, err := compute()
+	if err != nil {
+		return nil, err
+	}
+
+	m.statsMu.Lock()
+	maps.DeleteFunc(m.statsCache, func(_ statsKey, c cachedStats) bool {
+		return now.After(c.expires)
+	})
+	m.statsCache[key] = cachedStats{stats: stats, expires: now.Add(statsCacheTTL)}
+	m.statsMu.Unlock()
+
+	return &stats, nil
+}
+
+func (m *Manager) computeStats(ids []uint, created map[uint]time.Time, from, to time.Time) (Stats, error) {
+	stats := Stats{From: from, To: to}
+	if len(ids) == 0 {
+		return stats, nil
+	}
+
+	// Completed hours are read from their aggregates, only the raw checks of
+	// hours that are not rolled up yet are loaded. Aggregates of the hour
+	// from falls into start before the range, its raw checks are used instead
+	// while they are kept.
+	var (
+		firstHour = from.Truncate(time.Hour).Add(time.Hour)
+		recent    []string
+		args      []any
+	)
+	if from.Equal(from.Truncate(time.Hour)) {
+		firstHour = from
+	}
+	for _, id := range ids {
+		since, err := m.rolledUpUntil(id)
+		if err != nil {
+			return stats, fmt.Errorf("failed to load check aggregates: %w", err)
+		}
+		recent = append(recent, "(monitor_id = ? AND (created < ? OR created >= ?))")
+		args = append(args, id, firstHour, since)
+	}
+
+	checks := func() *gorm.DB {
+		return m.db.Model(&database.MonitorCheck{}).
+			Where("("+strings.Join(recent, " OR ")+")", args...).
+			Where("created >= ? AND created < ? AND maintenance = ?", from, to, false)
+	}
+
+	var counts struct {
+		Checks    int64
+		Successes int64
+	}
+	err := checks().
+		Select("COUNT(*) AS checks, COALESCE(SUM(CASE WHEN success THEN 1 ELSE 0 END), 0) AS successes").
+		Scan(&counts).Error
+	if err != nil {
+		return stats, fmt.Errorf("failed to count checks: %w", err)
+	}
+
+	// Failed checks usually time out or error early, so only successful checks
+	// are representative of the response time
+	var times []int64
+	err = checks().Where("success = ?", true).Order("response_time_ms").Pluck("response_time_ms", &times).Error
+	if err != nil {
+		return stats, fmt.Errorf("failed to load response times: %w", err)
+	}
+	stats.ResponseTime = responseTimeStats(times)
+
+	var rollups []database.MonitorCheckRollup
+	err = m.db.
+		Where("monitor_id IN ? AND bucket_start >= ? AND bucket_start < ?", ids, from, to).
+		Find(&rollups).Error
+	if err != nil {
+		return stats, fmt.Errorf("failed to load check aggregates: %w", err)
+	}
+
+	stats.Checks = counts.Checks
+	stats.SuccessfulChecks = counts.Successes
+	latencyTotal := stats.ResponseTime.Mean * float64(len(times))
+	for _, rollup := range rollups {
+		stats.Checks += rollup.Count
+		stats.SuccessfulChecks += rollup.Successes
+		latencyTotal += rollup.AvgMs * float64(rollup.Successes)
+	}
+
+	if stats.Checks > 0 {
+		uptime := float64(stats.SuccessfulChecks) / float64(stats.Checks) * 100
+		stats.Uptime = &uptime
+	}
+
+	if stats.SuccessfulChecks > 0 {
+		stats.ResponseTime.Mean = latencyTotal / float64(stats.SuccessfulChecks)
+	}
+
+	// Percentiles cover raw checks and aggregates alike, each aggregate counts
+	// as its successful checks at its average or p95. Aggregates only keep
+	// their p95, which also stands in for their p99.
+	if len(rollups) > 0 {
+		stats.ResponseTime.P50 = mergedPercentile(times, rollups, 50, func(r database.MonitorCheckRollup) int64 { return int64(r.AvgMs) })
+		stats.ResponseTime.P95 = mergedPercentile(times, rollups, 95, func(r database.MonitorCheckRollup) int64 { return r.P95Ms })
+		stats.ResponseTime.P99 = mergedPercentile(times, rollups, 99, func(r database.MonitorCheckRollup) int64 { return r.P95Ms })
+	}
+
+	var incidents []database.Incident
+	err = m.db.
+		Where("monitor_id IN ? AND started_at < ? AND (resolved_at IS NULL OR resolved_at > ?)", ids, to, from).
+		Find(&incidents).Error
+	if err != nil {
+		return stats, fmt.Errorf("failed to load incidents: %w", err)
+	}
+
+	var (
+		downtime  time.Duration
+		repairs   time.Duration
+		resolved  int
+		monitored time.Duration
+	)
+
+	for _, incident := range incidents {
+		end := to
+		if incident.ResolvedAt != nil && incident.ResolvedAt.Before(to) {
+			end = *incident.ResolvedAt
+		}
+		downtime += end.Sub(later(incident.StartedAt, from))
+
+		if !incident.StartedAt.Before(from) {
+			stats.Incidents++
+		}
+		if incident.ResolvedAt != nil && !incident.ResolvedAt.After(to) {
+			repairs += incident.ResolvedAt.Sub(incident.StartedAt)
+			resolved++
+		}
+	}
+
+	for _, id := range ids {
+		if start := later(created[id], from); start.Before(to) {
+			monitored += to.Sub(start)
+		}
+	}
+
+	stats.Downtime = downtime.Seconds()
+	if resolved > 0 {
+		stats.MTTR = repairs.Seconds() / float64(resolved)
+	}
+	if stats.Incidents > 0 {
+		stats.MTBF = max(monitored-downtime, 0).Seconds() / float64(stats.Incidents)
+	}
+
+	return stats, nil
+}
+
+// responseTimeStats expects the response times in ascending order.
+func responseTimeStats(times []int64) ResponseTimeStats {
+	if len(times) == 0 {
+		return ResponseTimeStats{}
+	}
+
+	var total int64
+	for _, t := range times {
+		total += t
+	}
+
+	return ResponseTimeStats{
+		Mean: float64(total) / float64(len(times)),
+		P50:  percentile(times, 50),
+		P95:  percentile(times, 95),
+		P99:  percentile(times, 99),
+	}
+}
+
+// percentile uses the nearest rank method on sorted values.
+func percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// mergedPercentile estimates a percentile from the sorted raw response times
+// and the aggregates, weighting the value of each aggregate by its number of
+// successful checks.
+func mergedPercentile(times []int64, rollups []database.MonitorCheckRollup, p float64, value func(database.MonitorCheckRollup) int64) int64 {
+	sorted := slices.SortedFunc(slices.Values(rollups), func(a, b database.MonitorCheckRollup) int {
+		return cmp.Compare(value(a), value(b))
+	})
+
+	total := int64(len(times))
+	for _, rollup := range sorted {
+		total += rollup.Successes
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var (
+		rank = max(int64(math.Ceil(p/100*float64(total))), 1)
+		seen int64
+		next int
+	)
+	// Walk both sorted sources in order of their values until the rank is
+	// reached
+	for _, rollup := range sorted {
+		for next < len(times) && times[next] <= value(rollup) {
+			if seen++; seen >= rank {
+				return times[next]
+			}
+			next++
+		}
+		if seen += rollup.Successes; seen >= rank {
+			return value(rollup)
+		}
+	}
+	for ; next < len(times); next++ {
+		if seen++; seen >= rank {
+			return times[next]
+		}
+	}
+	return times[len(times)-1]
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
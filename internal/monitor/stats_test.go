@@ -0,0 +1,101 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"honk/internal/database"
+)
+
+// Stats of a range must not change when its checks are rolled up, aggregates
+// replace the raw checks of their hour.
+func TestStatsAcrossRollups(t *testing.T) {
+	m := newTestManager(t)
+	m.RegisterHandler(database.ConnectionTypePush, NewPushHandler())
+
+	mon, err := m.AddMonitor(&database.Monitor{
+		Name:           "api",
+		ConnectionType: database.ConnectionTypePush,
+		Interval:       60,
+	})
+	if err != nil {
+		t.Fatalf("failed to add monitor: %v", err)
+	}
+
+	var (
+		now   = time.Now().Truncate(time.Hour)
+		start = now.Add(-3 * time.Hour)
+	)
+	var checks []database.MonitorCheck
+	for i := range 180 {
+		checks = append(checks, database.MonitorCheck{
+			MonitorID:      mon.ID,
+			Created:        start.Add(time.Duration(i) * time.Minute),
+			Success:        i%10 != 0,
+			ResponseTimeMs: int64(100 + i),
+			Maintenance:    i%60 == 59,
+		})
+	}
+	if err := m.db.Create(&checks).Error; err != nil {
+		t.Fatalf("failed to save checks: %v", err)
+	}
+
+	ranges := []struct {
+		name     string
+		from, to time.Time
+		checks   int64
+	}{
+		{"whole hours", start, now, 177},
+		{"starting within an hour", start.Add(30 * time.Minute), now, 147},
+		{"ending within an hour", start, now.Add(-30 * time.Minute), 148},
+	}
+
+	stats := func(from, to time.Time) Stats {
+		t.Helper()
+		s, err := m.computeStats([]uint{mon.ID}, map[uint]time.Time{mon.ID: start}, from, to)
+		if err != nil {
+			t.Fatalf("failed to compute stats: %v", err)
+		}
+		return s
+	}
+
+	before := make([]Stats, len(ranges))
+	for i, r := range ranges {
+		before[i] = stats(r.from, r.to)
+		if before[i].Checks != r.checks {
+			t.Errorf("%s: expected %d raw checks, got %d", r.name, r.checks, before[i].Checks)
+		}
+	}
+
+	if err := m.rollupChecks(now); err != nil {
+		t.Fatalf("failed to roll up checks: %v", err)
+	}
+	// A second run must not aggregate the same hours again
+	if err := m.rollupChecks(now); err != nil {
+		t.Fatalf("failed to roll up checks again: %v", err)
+	}
+
+	var rollups int64
+	m.db.Model(&database.MonitorCheckRollup{}).Count(&rollups)
+	if rollups != 3 {
+		t.Fatalf("expected 3 hourly aggregates, got %d", rollups)
+	}
+
+	for i, r := range ranges {
+		after := stats(r.from, r.to)
+		if r.name == "ending within an hour" {
+			// The aggregate of the last hour counts as a whole
+			if after.Checks != 177 {
+				t.Errorf("%s: expected the whole last hour, got %d checks", r.name, after.Checks)
+			}
+			continue
+		}
+		if after.Checks != before[i].Checks || after.SuccessfulChecks != before[i].SuccessfulChecks {
+			t.Errorf("%s: expected %d/%d checks, got %d/%d", r.name,
+				before[i].SuccessfulChecks, before[i].Checks, after.SuccessfulChecks, after.Checks)
+		}
+		if after.ResponseTime.Mean != before[i].ResponseTime.Mean {
+			t.Errorf("%s: expected a mean of %v, got %v", r.name, before[i].ResponseTime.Mean, after.ResponseTime.Mean)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package monitor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"honk/internal/database"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+const syncPollInterval = 10 * time.Second
+
+type SyncAction string
+
+const (
+	SyncCreate SyncAction = "create"
+	SyncUpdate SyncAction = "update"
+	SyncDelete SyncAction = "delete"
+)
+
+// SyncChange describes what a sync does to one monitor, Fields lists the
+// changed settings of updates.
+type SyncChange struct {
+	Action    SyncAction `json:"action"`
+	Key       string     `json:"key"`
+	Name      string     `json:"name"`
+	MonitorID uint       `json:"monitorId,omitempty"`
+	Fields    []string   `json:"fields,omitempty"`
+
+	spec *MonitorSpec
+}
+
+type SyncPlan struct {
+	Changes   []SyncChange `json:"changes"`
+	Unchanged int          `json:"unchanged"`
+}
+
+func (p *SyncPlan) String() string {
+	var b strings.Builder
+	for _, change := range p.Changes {
+		fmt.Fprintf(&b, "%s %s", change.Action, change.Key)
+		if len(change.Fields) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(change.Fields, ", "))
+		}
+		b.WriteString("; ")
+	}
+	fmt.Fprintf(&b, "%d unchanged", p.Unchanged)
+	return b.String()
+}
+
+// PlanSync compares the document with the current monitors. Monitors are
+// matched by key, a monitor without a key is adopted when its connection
+// matches. With prune, monitors with a key missing from the document are
+// deleted, monitors without a key are never touched.
+func (m *Manager) PlanSync(doc *MonitorsDocument, prune bool) (*SyncPlan, error) {
+	channels, err := m.channelNames()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byKey := make(map[string]*database.Monitor, len(m.monitors))
+	for _, mon := range m.monitors {
+		if mon.Key != "" {
+			byKey[mon.Key] = mon
+		}
+	}
+
+	keys := m.exportKeys()
+	plan := &SyncPlan{Changes: []SyncChange{}}
+	matched := make(map[uint]bool, len(doc.Monitors))
+
+	for i := range doc.Monitors {
+		spec := &doc.Monitors[i]
+
+		mon, ok := byKey[spec.Key]
+		if !ok {
+			mon = m.adoptable(spec)
+		}
+		if mon == nil {
+			plan.Changes = append(plan.Changes, SyncChange{Action: SyncCreate, Key: spec.Key, Name: spec.Name, spec: spec})
+			continue
+		}
+		matched[mon.ID] = true
+
+		current := toSpec(mon, keys, channels)
+		current.Key = mon.Key
+		fields := diffSpecs(current, *spec)
+		if len(fields) == 0 {
+			plan.Unchanged++
+			continue
+		}
+
+		plan.Changes = append(plan.Changes, SyncChange{
+			Action:    SyncUpdate,
+			Key:       spec.Key,
+			Name:      spec.Name,
+			MonitorID: mon.ID,
+			Fields:    fields,
+			spec:      spec,
+		})
+	}
+
+	if prune {
+		for _, mon := range byKey {
+			if !matched[mon.ID] {
+				plan.Changes = append(plan.Changes, SyncChange{Action: SyncDelete, Key: mon.Key, Name: mon.Name, MonitorID: mon.ID})
+			}
+		}
+	}
+
+	slices.SortStableFunc(plan.Changes, func(a, b SyncChange) int {
+		return strings.Compare(string(a.Action)+a.Key, string(b.Action)+b.Key)
+	})
+	return plan, nil
+}
+
+// adoptable returns a monitor without a key that the spec describes, the
+// caller holds mu.
+func (m *Manager) adoptable(spec *MonitorSpec) *database.Monitor {
+	if spec.ConnectionType == database.ConnectionTypePush {
+		return nil
+	}
+	for _, mon := range m.monitors {
+		if mon.Key == "" && mon.ConnectionType == spec.ConnectionType && mon.Connection == spec.Connection {
+			return mon
+		}
+	}
+	return nil
+}
+
+// diffSpecs returns the names of the fields that differ between the specs.
+func diffSpecs(current, desired MonitorSpec) []string {
+	var (
+		fields []string
+		a      = reflect.ValueOf(current)
+		b      = reflect.ValueOf(desired)
+	)
+
+	for i := range a.NumField() {
+		field := a.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		// Push connections are tokens generated by honk
+		if field.Name == "Connection" && desired.ConnectionType == database.ConnectionTypePush {
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// ApplySync brings the monitors in line with the document and returns the
+// applied plan. Failing changes are skipped and reported together.
+func (m *Manager) ApplySync(doc *MonitorsDocument, prune bool) (*SyncPlan, error) {
+	plan, err := m.PlanSync(doc, prune)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		errs    []error
+		pending []SyncChange
+	)
+
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case SyncDelete:
+			if err := m.RemoveMonitor(int(change.MonitorID)); err != nil {
+				errs = append(errs, fmt.Errorf("delete %s: %w", change.Key, err))
+			}
+		default:
+			pending = append(pending, change)
+		}
+	}
+
+	// Monitors are created once their parents exist, updates can be applied
+	// right away as the parents of existing monitors already exist
+	for len(pending) > 0 {
+		var (
+			deferred []SyncChange
+			progress bool
+		)
+
+		for _, change := range pending {
+			parentIDs, ok := m.resolveParents(change.spec.Parents)
+			if !ok {
+				deferred = append(deferred, change)
+				continue
+			}
+			progress = true
+
+			links, err := m.channelLinks(change.spec.Notifications)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", change.Action, change.Key, err))
+				continue
+			}
+
+			mon := change.spec.toMonitor(parentIDs, links)
+			if change.Action == SyncCreate {
+				if _, err := m.AddMonitor(mon); err != nil {
+					errs = append(errs, fmt.Errorf("create %s: %w", change.Key, err))
+				}
+				continue
+			}
+
+			mon.ID = change.MonitorID
+			mon.TeamID = m.teamOf(change.MonitorID)
+			if err := m.UpdateMonitor(mon); err != nil {
+				errs = append(errs, fmt.Errorf("update %s: %w", change.Key, err))
+			}
+		}
+
+		if !progress {
+			for _, change := range deferred {
+				errs = append(errs, fmt.Errorf("%s %s: %w: unknown parent in %v", change.Action, change.Key, ErrInvalidDependency, change.spec.Parents))
+			}
+			break
+		}
+		pending = deferred
+	}
+
+	return plan, errors.Join(errs...)
+}
+
+// resolveParents maps parent keys to monitor ids, ok is false while a parent
+// does not exist yet.
+func (m *Manager) resolveParents(keys []string) ([]uint, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]uint, 0, len(keys))
+	for _, key := range keys {
+		found := false
+		for _, mon := range m.monitors {
+			if mon.Key == key {
+				ids = append(ids, mon.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return ids, true
+}
+
+// teamOf returns the team of the monitor, teams are not part of the spec so
+// syncing keeps them.
+func (m *Manager) teamOf(id uint) *uint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if mon, ok := m.monitors[int(id)]; ok {
+		return mon.TeamID
+	}
+	return nil
+}
+
+// ReadMonitorsFile parses a desired state file, the format follows from the
+// extension.
+func ReadMonitorsFile(path string) (*MonitorsDocument, []byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read monitors file: %w", err)
+	}
+
+	format := FormatYAML
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = FormatJSON
+	}
+
+	doc, err := ParseMonitors(data, format)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse monitors file %s: %w", path, err)
+	}
+	return doc, data, nil
+}
+
+// SyncFile makes the file the desired state of all monitors. It is applied on
+// startup and again whenever its content changes, monitors with a key that
+// are removed from the file are deleted.
+func (m *Manager) SyncFile(path string) {
+	m.syncFile = path
+
+	m.wg.Add(1)
+	go m.watchMonitorsFile(path)
+}
+
+func (m *Manager) MonitorsFile() string {
+	return m.syncFile
+}
+
+func (m *Manager) watchMonitorsFile(path string) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+
+	var applied []byte
+	for {
+		doc, data, err := ReadMonitorsFile(path)
+		switch {
+		case err != nil:
+			if applied == nil || !errors.Is(err, os.ErrNotExist) {
+				log.Error("%v", err)
+			}
+		case !bytes.Equal(data, applied):
+			plan, err := m.ApplySync(doc, true)
+			if err != nil {
+				log.Error("failed to sync monitors from %s: %v", path, err)
+			}
+			if plan != nil {
+				log.Info("monitors synced from %s: %s", path, plan)
+				applied = data
+			}
+		}
+
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
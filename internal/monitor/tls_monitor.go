@@ -0,0 +1,191 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"honk/internal/database"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_CERT_EXPIRY_DAYS = 14
+)
+
+type TLSHandler struct {
+	Dialer *net.Dialer
+}
+
+func NewTLSHandler(timeout time.Duration) *TLSHandler {
+	return &TLSHandler{
+		Dialer: &net.Dialer{Timeout: timeout},
+	}
+}
+
+func (h *TLSHandler) Check(ctx context.Context, m *database.Monitor) (string, int64, error) {
+	host, addr, err := tlsAddress(m.Connection)
+	if err != nil {
+		return fmt.Sprintf("Invalid TLS connection %q: %v", m.Connection, err), 0, err
+	}
+
+	log.Debug("TLS handler '%s' connecting to %s", m.Name, addr)
+
+	dialer := &tls.Dialer{
+		NetDialer: h.Dialer,
+		Config: &tls.Config{
+			ServerName: host,
+			// The chain is verified below so certificate details can be
+			// reported even when verification fails.
+			InsecureSkipVerify: true,
+		},
+	}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	duration := time.Since(start).Milliseconds()
+	if err != nil {
+		return fmt.Sprintf("TLS handshake with %s failed: %v", addr, err), duration, err
+	}
+	defer func() {
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Warning("failed to close tls connection: %v", closeErr)
+		}
+	}()
+
+	state := conn.(*tls.Conn).ConnectionState()
+
+	expiryDays := m.CertExpiryDays
+	if expiryDays <= 0 {
+		expiryDays = DEFAULT_CERT_EXPIRY_DAYS
+	}
+
+	report, err := inspectCertificates(state.PeerCertificates, host, expiryDays, true, time.Now())
+	if report.Leaf != nil {
+		checkDetails(ctx).CertExpiresAt = &report.Leaf.NotAfter
+	}
+
+	if err != nil {
+		if m.CertExpiryWarnOnly && report.Verified {
+			log.Warning("certificate warning for monitor '%s': %v", m.Name, err)
+			return fmt.Sprintf("Warning: %v\n\n%s", err, report), duration, fmt.Errorf("%w: %v", ErrDegraded, err)
+		}
+		return fmt.Sprintf("%v\n\n%s", err, report), duration, err
+	}
+
+	return report.String(), duration, nil
+}
+
+// tlsAddress extracts the server name and dial address from a connection,
+// which may be "host", "host:port" or an https:// URL. Port 443 is assumed.
+func tlsAddress(connection string) (string, string, error) {
+	connection = strings.TrimSpace(connection)
+
+	if strings.Contains(connection, "://") {
+		u, err := url.Parse(connection)
+		if err != nil {
+			return "", "", err
+		}
+		connection = u.Host
+	}
+
+	host, port, err := net.SplitHostPort(connection)
+	if err != nil {
+		host, port = connection, "443"
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("missing host")
+	}
+
+	return host, net.JoinHostPort(host, port), nil
+}
+
+// certificateReport describes the certificates presented by a server.
+type certificateReport struct {
+	Leaf          *x509.Certificate
+	Chain         []*x509.Certificate
+	Verified      bool
+	DaysRemaining int
+}
+
+func (r certificateReport) String() string {
+	if r.Leaf == nil {
+		return "No certificate presented"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Subject: %s\n", certificateName(r.Leaf.Subject))
+	fmt.Fprintf(&b, "Issuer: %s\n", certificateName(r.Leaf.Issuer))
+	fmt.Fprintf(&b, "SANs: %s\n", strings.Join(certificateNames(r.Leaf), ", "))
+	fmt.Fprintf(&b, "Expires: %s (%d days remaining)", r.Leaf.NotAfter.UTC().Format(time.RFC3339), r.DaysRemaining)
+
+	for _, cert := range r.Chain[1:] {
+		fmt.Fprintf(&b, "\nIntermediate: %s, expires %s", certificateName(cert.Subject), cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	return b.String()
+}
+
+func certificateName(name pkix.Name) string {
+	if name.CommonName != "" {
+		return name.CommonName
+	}
+	return name.String()
+}
+
+func certificateNames(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
+
+// inspectCertificates validates the presented chain and fails when the leaf or
+// any intermediate expires within expiryDays. Chain verification is skipped
+// when verify is false, e.g. when the HTTP client already performed it.
+func inspectCertificates(certs []*x509.Certificate, host string, expiryDays int, verify bool, now time.Time) (certificateReport, error) {
+	report := certificateReport{Chain: certs, Verified: !verify}
+	if len(certs) == 0 {
+		return report, fmt.Errorf("server presented no certificates")
+	}
+
+	report.Leaf = certs[0]
+	report.DaysRemaining = int(report.Leaf.NotAfter.Sub(now).Hours() / 24)
+
+	if verify {
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		if _, err := report.Leaf.Verify(x509.VerifyOptions{
+			DNSName:       host,
+			Intermediates: intermediates,
+			CurrentTime:   now,
+		}); err != nil {
+			return report, fmt.Errorf("certificate verification failed: %w", err)
+		}
+		report.Verified = true
+	}
+
+	threshold := now.Add(time.Duration(expiryDays) * 24 * time.Hour)
+	for i, cert := range certs {
+		if cert.NotAfter.After(threshold) {
+			continue
+		}
+
+		kind := "certificate"
+		if i > 0 {
+			kind = "intermediate certificate"
+		}
+		days := int(cert.NotAfter.Sub(now).Hours() / 24)
+		return report, fmt.Errorf("%s %s expires in %d days (threshold %d days)", kind, certificateName(cert.Subject), days, expiryDays)
+	}
+
+	return report, nil
+}
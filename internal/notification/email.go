@@ -1,29 +1,97 @@
 package notification
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"html"
+	"html/template"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
-type EmailNotifier struct {
-	SMTPHost string
-	SMTPPort string
+type SMTPSecurity string
+
+const (
+	SMTPStartTLS SMTPSecurity = "starttls"
+	SMTPTLS      SMTPSecurity = "tls"
+	SMTPNone     SMTPSecurity = "none"
+
+	DEFAULT_SMTP_TIMEOUT = 10 * time.Second
+)
+
+var ErrSMTPNotConfigured = errors.New("smtp is not configured")
+
+// SMTPSettings are shared by all email channels.
+type SMTPSettings struct {
+	Host     string
+	Port     int
 	Username string
 	Password string
 	From     string
-	To       []string
+	Security SMTPSecurity
+	Timeout  time.Duration
+}
+
+func (s SMTPSettings) Configured() bool {
+	return s.Host != "" && s.From != ""
 }
 
-func NewEmailNotifier(smtpHost, smtpPort, username, password, from string, to []string) *EmailNotifier {
-	return &EmailNotifier{
-		SMTPHost: smtpHost,
-		SMTPPort: smtpPort,
-		Username: username,
-		Password: password,
-		From:     from,
-		To:       to,
+type EmailNotifier struct {
+	SMTP SMTPSettings
+	To   []string
+
+	// Replaces the TLS config derived from the settings, e.g. to trust a
+	// test certificate
+	tlsConfig *tls.Config
+}
+
+func NewEmailNotifier(settings SMTPSettings, to []string) (*EmailNotifier, error) {
+	if !settings.Configured() {
+		return nil, ErrSMTPNotConfigured
 	}
+	if len(to) == 0 {
+		return nil, errors.New("no email recipients")
+	}
+
+	return &EmailNotifier{SMTP: settings, To: to}, nil
+}
+
+// ParseRecipients splits a comma or semicolon separated list of email
+// addresses.
+func ParseRecipients(list string) ([]string, error) {
+	var recipients []string
+	for _, field := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ';' }) {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		addr, err := mail.ParseAddress(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address %q: %w", field, err)
+		}
+		recipients = append(recipients, addr.Address)
+	}
+
+	if len(recipients) == 0 {
+		return nil, errors.New("no email recipients")
+	}
+	return recipients, nil
 }
 
 func (e *EmailNotifier) Send(msg Message) error {
@@ -31,46 +99,308 @@ func (e *EmailNotifier) Send(msg Message) error {
 		msg.Timestamp = time.Now()
 	}
 
+	if err := msg.RenderTemplate(); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	message, err := e.buildMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := e.deliver(message); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+func (e *EmailNotifier) SendInfo(title, text string) error {
+	return e.Send(Message{Title: title, Text: text, Level: "info"})
+}
+
+func (e *EmailNotifier) SendError(title, text string) error {
+	return e.Send(Message{Title: title, Text: text, Level: "error"})
+}
+
+func (e *EmailNotifier) SendWarning(title, text string) error {
+	return e.Send(Message{Title: title, Text: text, Level: "warning"})
+}
+
+// buildMessage renders a multipart/alternative message with a plain text and
+// an HTML part.
+func (e *EmailNotifier) buildMessage(msg Message) ([]byte, error) {
 	subject := msg.Title
 	if msg.Level != "" {
 		subject = fmt.Sprintf("[%s] %s", msg.Level, msg.Title)
 	}
 
-	body := fmt.Sprintf("Time: %s\n\n%s\n", msg.Timestamp.Format(time.RFC1123), msg.Text)
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	text := fmt.Sprintf("%s\n\nTime: %s\n\n%s\n", msg.Title, msg.Timestamp.Format(time.RFC1123), msg.Text)
+	for _, k := range sortedKeys(msg.Data) {
+		text += fmt.Sprintf("\n%s: %v", k, msg.Data[k])
+	}
 
-	if len(msg.Data) > 0 {
-		body += "\nAdditional Data:\n"
-		for k, v := range msg.Data {
-			body += fmt.Sprintf("  %s: %v\n", k, v)
+	htmlBody, err := renderEmailHTML(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render email: %w", err)
+	}
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", htmlBody},
+	} {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
 		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	for _, header := range [][2]string{
+		{"From", e.SMTP.From},
+		{"To", strings.Join(e.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", msg.Timestamp.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(e.SMTP.From)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + writer.Boundary()},
+	} {
+		fmt.Fprintf(&message, "%s: %s\r\n", header[0], header[1])
 	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
 
-	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
-		e.From,
-		e.To[0],
-		subject,
-		body,
+	return message.Bytes(), nil
+}
+
+func (e *EmailNotifier) deliver(message []byte) error {
+	settings := e.SMTP
+	timeout := settings.Timeout
+	if timeout <= 0 {
+		timeout = DEFAULT_SMTP_TIMEOUT
+	}
+
+	port := settings.Port
+	if port == 0 {
+		port = 587
+		if settings.Security == SMTPTLS {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(settings.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: settings.Host}
+	if e.tlsConfig != nil {
+		tlsConfig = e.tlsConfig
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var (
+		conn net.Conn
+		err  error
 	)
+	if settings.Security == SMTPTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	client, err := smtp.NewClient(conn, settings.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if settings.Security == SMTPStartTLS || settings.Security == "" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
 
-	auth := smtp.PlainAuth("", e.Username, e.Password, e.SMTPHost)
-	addr := fmt.Sprintf("%s:%s", e.SMTPHost, e.SMTPPort)
+	if settings.Username != "" {
+		auth, err := chooseAuth(client, settings)
+		if err != nil {
+			return err
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
 
-	err := smtp.SendMail(addr, auth, e.From, e.To, []byte(message))
+	if err := client.Mail(addressOf(settings.From)); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
 	}
 
-	return nil
+	return client.Quit()
 }
 
-func (e *EmailNotifier) SendInfo(title, text string) error {
-	return e.Send(Message{Title: title, Text: text, Level: "info"})
+// chooseAuth picks the first mechanism offered by the server out of PLAIN,
+// LOGIN and CRAM-MD5.
+func chooseAuth(client *smtp.Client, settings SMTPSettings) (smtp.Auth, error) {
+	ok, params := client.Extension("AUTH")
+	if !ok {
+		return nil, errors.New("server does not support authentication")
+	}
+
+	mechanisms := strings.Fields(strings.ToUpper(params))
+	switch {
+	case slices.Contains(mechanisms, "PLAIN"):
+		return smtp.PlainAuth("", settings.Username, settings.Password, settings.Host), nil
+	case slices.Contains(mechanisms, "LOGIN"):
+		return &loginAuth{username: settings.Username, password: settings.Password}, nil
+	case slices.Contains(mechanisms, "CRAM-MD5"):
+		return smtp.CRAMMD5Auth(settings.Username, settings.Password), nil
+	}
+	return nil, fmt.Errorf("no supported authentication mechanism in %q", params)
 }
 
-func (e *EmailNotifier) SendError(title, text string) error {
-	return e.Send(Message{Title: title, Text: text, Level: "error"})
+// loginAuth implements the LOGIN mechanism still required by some providers.
+type loginAuth struct {
+	username, password string
 }
 
-func (e *EmailNotifier) SendWarning(title, text string) error {
-	return e.Send(Message{Title: title, Text: text, Level: "warning"})
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && server.Name != "localhost" && server.Name != "127.0.0.1" {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch strings.ToLower(strings.TrimSuffix(string(fromServer), ":")) {
+	case "username":
+		return []byte(a.username), nil
+	case "password":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+}
+
+var (
+	emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background:#f4f4f5;font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;color:#18181b">
+<div style="max-width:600px;margin:0 auto;background:#ffffff;border-top:4px solid {{.Color}};border-radius:6px;padding:24px">
+<h2 style="margin:0 0 4px">{{.Title}}</h2>
+<p style="margin:0 0 16px;color:#71717a;font-size:13px">{{.Time}}</p>
+{{range .Blocks}}{{if .Code}}<pre style="background:#f4f4f5;padding:12px;border-radius:4px;white-space:pre-wrap;font-size:13px">{{.Content}}</pre>
+{{else}}<p style="line-height:1.5">{{.Content}}</p>
+{{end}}{{end}}{{if .Data}}<table style="font-size:13px">{{range .Data}}<tr><td style="padding-right:12px;color:#71717a">{{.Key}}</td><td>{{.Value}}</td></tr>{{end}}</table>
+{{end}}</div>
+</body>
+</html>
+`))
+
+	boldPattern = regexp.MustCompile(`\*\*(.+?)\*\*`)
+)
+
+type emailBlock struct {
+	Code    bool
+	Content template.HTML
+}
+
+// renderEmailHTML renders the text of the message, fenced code blocks become
+// preformatted blocks and **text** is shown bold.
+func renderEmailHTML(msg Message) (string, error) {
+	var blocks []emailBlock
+	for i, segment := range strings.Split(msg.Text, "```") {
+		if i%2 == 1 {
+			blocks = append(blocks, emailBlock{Code: true, Content: template.HTML(html.EscapeString(strings.Trim(segment, "\n")))})
+			continue
+		}
+
+		for _, paragraph := range strings.Split(segment, "\n\n") {
+			paragraph = strings.TrimSpace(paragraph)
+			if paragraph == "" {
+				continue
+			}
+			content := boldPattern.ReplaceAllString(html.EscapeString(paragraph), "<strong>$1</strong>")
+			content = strings.ReplaceAll(content, "\n", "<br>")
+			blocks = append(blocks, emailBlock{Content: template.HTML(content)})
+		}
+	}
+
+	type dataRow struct {
+		Key   string
+		Value any
+	}
+	var data []dataRow
+	for _, k := range sortedKeys(msg.Data) {
+		data = append(data, dataRow{k, msg.Data[k]})
+	}
+
+	var buf bytes.Buffer
+	err := emailTemplate.Execute(&buf, map[string]any{
+		"Title":  msg.Title,
+		"Time":   msg.Timestamp.Format(time.RFC1123),
+		"Color":  fmt.Sprintf("#%06X", levelColor(msg.Level)),
+		"Blocks": blocks,
+		"Data":   data,
+	})
+	return buf.String(), err
+}
+
+func sortedKeys(data map[string]interface{}) []string {
+	return slices.Sorted(maps.Keys(data))
+}
+
+func addressOf(from string) string {
+	if addr, err := mail.ParseAddress(from); err == nil {
+		return addr.Address
+	}
+	return from
+}
+
+func messageID(from string) string {
+	domain := "honk"
+	if at := strings.LastIndex(addressOf(from), "@"); at >= 0 {
+		domain = addressOf(from)[at+1:]
+	}
+
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
 }
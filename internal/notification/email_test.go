@@ -0,0 +1,448 @@
+package notification
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the stub server received during one connection.
+type smtpSession struct {
+	TLS       bool
+	Mechanism string
+	Username  string
+	Password  string
+	From      string
+	To        []string
+	Data      string
+}
+
+// smtpStub is a minimal SMTP server accepting a single connection. It offers
+// the given AUTH mechanisms and STARTTLS when a TLS config is set.
+type smtpStub struct {
+	listener   net.Listener
+	mechanisms string
+	tlsConfig  *tls.Config
+	reject     string
+
+	sessions chan smtpSession
+}
+
+func newSMTPStub(t *testing.T, mechanisms string, tlsConfig *tls.Config) *smtpStub {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	stub := &smtpStub{
+		listener:   listener,
+		mechanisms: mechanisms,
+		tlsConfig:  tlsConfig,
+		sessions:   make(chan smtpSession, 1),
+	}
+	go stub.serve()
+	return stub
+}
+
+func (s *smtpStub) settings(security SMTPSecurity) SMTPSettings {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return SMTPSettings{
+		Host:     "127.0.0.1",
+		Port:     addr.Port,
+		Username: "honk",
+		Password: "s3cret",
+		From:     "Honk <honk@example.com>",
+		Security: security,
+		Timeout:  2 * time.Second,
+	}
+}
+
+// session waits for the session of the connection to end.
+func (s *smtpStub) session(t *testing.T) smtpSession {
+	t.Helper()
+
+	select {
+	case session := <-s.sessions:
+		return session
+	case <-time.After(2 * time.Second):
+		t.Fatal("smtp session did not end")
+		return smtpSession{}
+	}
+}
+
+func (s *smtpStub) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var session smtpSession
+	defer func() { s.sessions <- session }()
+
+	text := textproto.NewConn(conn)
+	reply := func(code int, lines ...string) {
+		for i, line := range lines {
+			sep := "-"
+			if i == len(lines)-1 {
+				sep = " "
+			}
+			_ = text.PrintfLine("%d%s%s", code, sep, line)
+		}
+	}
+
+	reply(220, "stub ready")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			extensions := []string{"stub"}
+			if s.tlsConfig != nil && !session.TLS {
+				extensions = append(extensions, "STARTTLS")
+			}
+			if s.mechanisms != "" {
+				extensions = append(extensions, "AUTH "+s.mechanisms)
+			}
+			reply(250, extensions...)
+		case "STARTTLS":
+			reply(220, "go ahead")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, text, session.TLS = tlsConn, textproto.NewConn(tlsConn), true
+		case "AUTH":
+			if !s.authenticate(text, arg, &session) {
+				reply(535, "authentication failed")
+				continue
+			}
+			reply(235, "authenticated")
+		case "MAIL":
+			session.From = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply(250, "ok")
+		case "RCPT":
+			to := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if to == s.reject {
+				reply(550, "no such user")
+				continue
+			}
+			session.To = append(session.To, to)
+			reply(250, "ok")
+		case "DATA":
+			reply(354, "send data")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			session.Data = string(data)
+			reply(250, "queued")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "not implemented")
+		}
+	}
+}
+
+// authenticate runs the exchange of the mechanism and records the
+// credentials.
+func (s *smtpStub) authenticate(text *textproto.Conn, arg string, session *smtpSession) bool {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	session.Mechanism = mechanism
+
+	challenge := func(prompt string) string {
+		_ = text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+		line, _ := text.ReadLine()
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		return string(decoded)
+	}
+
+	switch mechanism {
+	case "PLAIN":
+		decoded, err := base64.StdEncoding.DecodeString(initial)
+		if err != nil {
+			return false
+		}
+		parts := strings.Split(string(decoded), "\x00")
+		if len(parts) != 3 {
+			return false
+		}
+		session.Username, session.Password = parts[1], parts[2]
+	case "LOGIN":
+		session.Username = challenge("Username:")
+		session.Password = challenge("Password:")
+	case "CRAM-MD5":
+		nonce := "<1896.697170952@stub>"
+		username, digest, _ := strings.Cut(challenge(nonce), " ")
+		mac := hmac.New(md5.New, []byte("s3cret"))
+		mac.Write([]byte(nonce))
+		if digest != hex.EncodeToString(mac.Sum(nil)) {
+			return false
+		}
+		session.Username, session.Password = username, "s3cret"
+	default:
+		return false
+	}
+	return true
+}
+
+// testTLSConfigs returns a server config with a certificate for 127.0.0.1
+// and a client config trusting it.
+func testTLSConfigs(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(ts.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+
+	server = &tls.Config{Certificates: ts.TLS.Certificates}
+	client = &tls.Config{ServerName: "127.0.0.1", RootCAs: pool}
+	return server, client
+}
+
+func TestEmailNotifierSend(t *testing.T) {
+	stub := newSMTPStub(t, "", nil)
+	settings := stub.settings(SMTPNone)
+	settings.Username = ""
+
+	notifier, err := NewEmailNotifier(settings, []string{"ops@example.com", "oncall@example.com"})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+
+	err = notifier.Send(Message{
+		Title:     "API is down",
+		Text:      "Checks fail since **12:00**\n\n```\n<html> 502\n```",
+		Level:     "error",
+		Timestamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Data:      map[string]interface{}{"monitor": "api"},
+	})
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	session := stub.session(t)
+	if session.From != "honk@example.com" {
+		t.Errorf("expected envelope sender honk@example.com, got %q", session.From)
+	}
+	if got := strings.Join(session.To, ","); got != "ops@example.com,oncall@example.com" {
+		t.Errorf("unexpected recipients %q", got)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(session.Data))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+
+	for header, want := range map[string]string{
+		"From":         "Honk <honk@example.com>",
+		"To":           "ops@example.com, oncall@example.com",
+		"Subject":      "[error] API is down",
+		"MIME-Version": "1.0",
+	} {
+		got := msg.Header.Get(header)
+		if decoded, err := new(mime.WordDecoder).DecodeHeader(got); err == nil {
+			got = decoded
+		}
+		if got != want {
+			t.Errorf("header %s: expected %q, got %q", header, want, got)
+		}
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %q", msg.Header.Get("Content-Type"))
+	}
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid part: %v", err)
+		}
+
+		// Quoted-printable parts are decoded by the reader
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		parts[strings.Split(part.Header.Get("Content-Type"), ";")[0]] = string(body)
+	}
+
+	if text := parts["text/plain"]; !strings.Contains(text, "Checks fail since **12:00**") || !strings.Contains(text, "monitor: api") {
+		t.Errorf("unexpected plain text part:\n%s", text)
+	}
+
+	html := parts["text/html"]
+	for _, want := range []string{"<strong>12:00</strong>", "&lt;html&gt; 502", "<h2 style=\"margin:0 0 4px\">API is down</h2>", ">api</td>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html part does not contain %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestEmailNotifierStartTLS(t *testing.T) {
+	serverTLS, clientTLS := testTLSConfigs(t)
+	stub := newSMTPStub(t, "PLAIN", serverTLS)
+
+	notifier, err := NewEmailNotifier(stub.settings(SMTPStartTLS), []string{"ops@example.com"})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+	notifier.tlsConfig = clientTLS
+
+	if err := notifier.SendInfo("Test", "Hello"); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	session := stub.session(t)
+	if !session.TLS {
+		t.Error("expected the session to be upgraded with STARTTLS")
+	}
+	if session.Username != "honk" || session.Password != "s3cret" {
+		t.Errorf("unexpected credentials %q/%q", session.Username, session.Password)
+	}
+	if session.Data == "" {
+		t.Error("expected a message to be sent")
+	}
+}
+
+func TestEmailNotifierRequiresStartTLS(t *testing.T) {
+	stub := newSMTPStub(t, "PLAIN", nil)
+
+	notifier, err := NewEmailNotifier(stub.settings(SMTPStartTLS), []string{"ops@example.com"})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+
+	err = notifier.SendInfo("Test", "Hello")
+	if err == nil || !strings.Contains(err.Error(), "server does not support STARTTLS") {
+		t.Fatalf("expected STARTTLS to be required, got %v", err)
+	}
+
+	if session := stub.session(t); session.Username != "" || session.Data != "" {
+		t.Errorf("credentials or message sent without TLS: %+v", session)
+	}
+}
+
+func TestEmailNotifierAuthSelection(t *testing.T) {
+	tests := []struct {
+		offered string
+		want    string
+	}{
+		{offered: "LOGIN PLAIN CRAM-MD5", want: "PLAIN"},
+		{offered: "CRAM-MD5 LOGIN", want: "LOGIN"},
+		{offered: "cram-md5", want: "CRAM-MD5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.offered, func(t *testing.T) {
+			stub := newSMTPStub(t, tt.offered, nil)
+
+			notifier, err := NewEmailNotifier(stub.settings(SMTPNone), []string{"ops@example.com"})
+			if err != nil {
+				t.Fatalf("failed to create notifier: %v", err)
+			}
+			if err := notifier.SendInfo("Test", "Hello"); err != nil {
+				t.Fatalf("failed to send: %v", err)
+			}
+
+			session := stub.session(t)
+			if session.Mechanism != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, session.Mechanism)
+			}
+			if session.Username != "honk" || session.Password != "s3cret" {
+				t.Errorf("unexpected credentials %q/%q", session.Username, session.Password)
+			}
+		})
+	}
+}
+
+func TestEmailNotifierUnsupportedAuth(t *testing.T) {
+	stub := newSMTPStub(t, "XOAUTH2", nil)
+
+	notifier, err := NewEmailNotifier(stub.settings(SMTPNone), []string{"ops@example.com"})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+
+	err = notifier.SendInfo("Test", "Hello")
+	if err == nil || !strings.Contains(err.Error(), "no supported authentication mechanism") {
+		t.Fatalf("expected no mechanism to be found, got %v", err)
+	}
+	stub.session(t)
+}
+
+func TestEmailNotifierRejectedRecipient(t *testing.T) {
+	stub := newSMTPStub(t, "", nil)
+	stub.reject = "gone@example.com"
+	settings := stub.settings(SMTPNone)
+	settings.Username = ""
+
+	notifier, err := NewEmailNotifier(settings, []string{"ops@example.com", "gone@example.com"})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+
+	err = notifier.SendInfo("Test", "Hello")
+	if err == nil || !strings.Contains(err.Error(), "recipient gone@example.com rejected") {
+		t.Fatalf("expected the recipient to be rejected, got %v", err)
+	}
+	if session := stub.session(t); session.Data != "" {
+		t.Error("message sent despite a rejected recipient")
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    string
+		wantErr bool
+	}{
+		{list: "ops@example.com", want: "ops@example.com"},
+		{list: "Ops <ops@example.com>; oncall@example.com,", want: "ops@example.com,oncall@example.com"},
+		{list: " , ", wantErr: true},
+		{list: "not an address", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			got, err := ParseRecipients(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if joined := strings.Join(got, ","); joined != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, joined)
+			}
+		})
+	}
+}
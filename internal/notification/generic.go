@@ -0,0 +1,145 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const DEFAULT_WEBHOOK_BODY = `{
+  "title": {{json .Title}},
+  "text": {{json .Text}},
+  "level": {{json .Level}},
+  "name": {{json .Name}},
+  "connection": {{json .Connection}},
+  "error": {{json .Error}},
+  "downtime": {{json .Downtime}},
+  "timestamp": {{json .Timestamp}}
+}`
+
+var webhookMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+
+// WebhookOptions configures the request of a generic webhook.
+type WebhookOptions struct {
+	Method  string
+	Headers map[string]string
+
+	// Signs the body with HMAC-SHA256 when set, see WebhookNotifier
+	Secret string
+
+	// Go template of the body, DEFAULT_WEBHOOK_BODY when empty
+	Body string
+}
+
+func (o WebhookOptions) Validate() error {
+	if o.Method != "" && !slices.Contains(webhookMethods, strings.ToUpper(o.Method)) {
+		return fmt.Errorf("unsupported webhook method %q, expected one of %s", o.Method, strings.Join(webhookMethods, ", "))
+	}
+
+	for name := range o.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("invalid webhook header %q", name)
+		}
+	}
+
+	if _, err := NewGenericBuilder(o.Body); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GenericBuilder renders a user defined body. The template is executed with
+// the TemplateData of the message together with its Title and Text, the json
+// and jsonEscape functions keep quotes and newlines from breaking the payload.
+type GenericBuilder struct {
+	body *template.Template
+}
+
+// webhookData is the data of generic webhook templates.
+type webhookData struct {
+	*TemplateData
+	Title string
+	Text  string
+}
+
+// TemplateFuncs are available in webhook bodies and in the request bodies of
+// HTTP monitors.
+var TemplateFuncs = template.FuncMap{
+	// json encodes the value as JSON, strings include the quotes
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// jsonEscape escapes a string for use inside a quoted JSON string
+	"jsonEscape": func(s string) (string, error) {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return "", err
+		}
+		return string(b[1 : len(b)-1]), nil
+	},
+}
+
+func NewGenericBuilder(body string) (*GenericBuilder, error) {
+	if body == "" {
+		body = DEFAULT_WEBHOOK_BODY
+	}
+
+	tmpl, err := template.New("webhook").Funcs(TemplateFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook body template: %w", err)
+	}
+	return &GenericBuilder{body: tmpl}, nil
+}
+
+func (g *GenericBuilder) Build(msg Message) ([]byte, error) {
+	data := webhookData{
+		TemplateData: msg.TemplateData,
+		Title:        msg.Title,
+		Text:         msg.Text,
+	}
+	if data.TemplateData == nil {
+		data.TemplateData = &TemplateData{
+			Timestamp: msg.Timestamp.Format(time.RFC3339),
+			Level:     string(msg.Level),
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := g.body.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render webhook body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// NewGenericWebhookNotifier sends the body rendered from the options to url.
+func NewGenericWebhookNotifier(url string, opts WebhookOptions) (*WebhookNotifier, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	builder, err := NewGenericBuilder(opts.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(opts.Headers))
+	for name, value := range opts.Headers {
+		headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+
+	return &WebhookNotifier{
+		URL:     url,
+		Method:  strings.ToUpper(opts.Method),
+		Headers: headers,
+		Secret:  opts.Secret,
+		Builder: builder,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
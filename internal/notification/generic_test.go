@@ -0,0 +1,206 @@
+package notification
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func genericMessage() Message {
+	return Message{
+		Title:     `api is "down"`,
+		Text:      "connection refused\nafter 3 retries",
+		Level:     Error,
+		Timestamp: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		TemplateData: &TemplateData{
+			Name:       `api "eu"`,
+			Connection: "https://api.example.com",
+			Error:      "dial tcp: connection refused",
+			Level:      "error",
+			Timestamp:  "2026-01-01T12:00:00Z",
+		},
+	}
+}
+
+func TestGenericBuilder(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		msg      func() Message
+		expected map[string]any
+	}{
+		{
+			name: "default body",
+			msg:  genericMessage,
+			expected: map[string]any{
+				"title": `api is "down"`,
+				"text":  "connection refused\nafter 3 retries",
+				"name":  `api "eu"`,
+				"level": "error",
+			},
+		},
+		{
+			name: "json escape inside strings",
+			body: `{"summary": "{{jsonEscape .Name}}: {{jsonEscape .Text}}"}`,
+			msg:  genericMessage,
+			expected: map[string]any{
+				"summary": "api \"eu\": connection refused\nafter 3 retries",
+			},
+		},
+		{
+			name: "json of other values",
+			body: `{"reminder": {{json .Reminder}}, "custom": {{json .Custom}}}`,
+			msg: func() Message {
+				msg := genericMessage()
+				msg.TemplateData.Reminder = 2
+				msg.TemplateData.Custom = map[string]any{"region": "eu"}
+				return msg
+			},
+			expected: map[string]any{
+				"reminder": float64(2),
+				"custom":   map[string]any{"region": "eu"},
+			},
+		},
+		{
+			name: "messages without template data",
+			body: `{"level": {{json .Level}}, "timestamp": {{json .Timestamp}}, "name": {{json .Name}}}`,
+			msg: func() Message {
+				msg := genericMessage()
+				msg.TemplateData = nil
+				return msg
+			},
+			expected: map[string]any{
+				"level":     "error",
+				"timestamp": "2026-01-01T12:00:00Z",
+				"name":      "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder, err := NewGenericBuilder(tt.body)
+			if err != nil {
+				t.Fatalf("failed to parse template: %v", err)
+			}
+
+			payload, err := builder.Build(tt.msg())
+			if err != nil {
+				t.Fatalf("failed to build payload: %v", err)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(payload, &got); err != nil {
+				t.Fatalf("payload is not valid JSON: %v\n%s", err, payload)
+			}
+			for key, want := range tt.expected {
+				if gotJSON, wantJSON := mustJSON(t, got[key]), mustJSON(t, want); gotJSON != wantJSON {
+					t.Errorf("%s: expected %s, got %s", key, wantJSON, gotJSON)
+				}
+			}
+		})
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to encode %v: %v", v, err)
+	}
+	return string(b)
+}
+
+func TestWebhookOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options WebhookOptions
+		valid   bool
+	}{
+		{"defaults", WebhookOptions{}, true},
+		{"lower case method", WebhookOptions{Method: "put"}, true},
+		{"unsupported method", WebhookOptions{Method: http.MethodGet}, false},
+		{"header", WebhookOptions{Headers: map[string]string{"X-Token": "secret"}}, true},
+		{"header with colon", WebhookOptions{Headers: map[string]string{"X-Token:": "secret"}}, false},
+		{"header with newline", WebhookOptions{Headers: map[string]string{"X-Token\r\nHost": "secret"}}, false},
+		{"template syntax", WebhookOptions{Body: `{"name": {{json .Name}`}, false},
+		{"unknown function", WebhookOptions{Body: `{"name": {{upper .Name}}}`}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.options.Validate(); (err == nil) != tt.valid {
+				t.Errorf("expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestGenericBuilderUnknownField(t *testing.T) {
+	builder, err := NewGenericBuilder(`{"owner": {{json .Owner}}}`)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	if _, err := builder.Build(genericMessage()); err == nil {
+		t.Error("expected unknown fields to fail rendering")
+	}
+}
+
+func TestGenericWebhookRequest(t *testing.T) {
+	var (
+		method  string
+		headers http.Header
+		body    []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, headers = r.Method, r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	notifier, err := NewGenericWebhookNotifier(server.URL, WebhookOptions{
+		Method:  "patch",
+		Headers: map[string]string{"x-api-key": "key"},
+		Secret:  "signing-secret",
+		Body:    `{"name": {{json .Name}}}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+
+	msg := genericMessage()
+	if err := notifier.Send(msg); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	if method != http.MethodPatch {
+		t.Errorf("expected PATCH, got %s", method)
+	}
+	if headers.Get("X-Api-Key") != "key" || headers.Get("Content-Type") != "application/json" {
+		t.Errorf("expected the configured headers, got %v", headers)
+	}
+	if string(body) != `{"name": "api \"eu\""}` {
+		t.Errorf("unexpected body %s", body)
+	}
+
+	timestamp := strconv.FormatInt(msg.Timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte("signing-secret"))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	if headers.Get("X-Honk-Timestamp") != timestamp || headers.Get("X-Honk-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("unexpected signature headers %q %q", headers.Get("X-Honk-Timestamp"), headers.Get("X-Honk-Signature"))
+	}
+
+	if !strings.HasPrefix(headers.Get("User-Agent"), "honk-notifier/") {
+		t.Errorf("unexpected user agent %q", headers.Get("User-Agent"))
+	}
+}
@@ -17,6 +17,8 @@ type TemplateData struct {
 	Connection string
 	Error      string
 	Level      string
+	Downtime   string
+	Reminder   int
 
 	Custom map[string]interface{}
 }
@@ -64,6 +66,11 @@ func (m *Message) RenderTemplate() error {
 	return nil
 }
 
+// Sender delivers messages to a single channel.
+type Sender interface {
+	Send(msg Message) error
+}
+
 type Notifier interface {
 	Send(msg Message) error
 	SendInfo(title, text string) error
@@ -1,18 +1,94 @@
 package notification
 
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
 type Platform string
 type Level string
+type Event string
 
 const (
 	Slack   Platform = "slack"
 	Discord Platform = "discord"
 	Teams   Platform = "teams"
+	Webhook Platform = "webhook"
+	Email   Platform = "email"
 
 	Success Level = "success"
 	Warning Level = "warning"
 	Error   Level = "error"
+
+	EventDown     Event = "down"
+	EventUp       Event = "up"
+	EventReminder Event = "reminder"
+	EventDegraded Event = "degraded"
+)
+
+var ErrUnsupportedPlatform = errors.New("unsupported notification platform")
+
+var (
+	buildersMu sync.RWMutex
+	builders   = map[Platform]func() PayloadBuilder{
+		Discord: func() PayloadBuilder { return NewDiscordBuilder() },
+		Slack:   func() PayloadBuilder { return SlackBuilder{} },
+		Teams:   func() PayloadBuilder { return TeamsBuilder{} },
+		Webhook: func() PayloadBuilder {
+			builder, _ := NewGenericBuilder("")
+			return builder
+		},
+	}
 )
 
+// RegisterBuilder makes the payload builder of a webhook platform available,
+// replacing any builder registered for the same platform.
+func RegisterBuilder(platform Platform, builder func() PayloadBuilder) {
+	buildersMu.Lock()
+	defer buildersMu.Unlock()
+	builders[platform] = builder
+}
+
+// NewBuilder returns the payload builder registered for the platform.
+func NewBuilder(platform Platform) (PayloadBuilder, error) {
+	buildersMu.RLock()
+	builder, ok := builders[platform]
+	buildersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedPlatform, platform)
+	}
+	return builder(), nil
+}
+
+// WebhookPlatforms lists the platforms with a registered payload builder.
+func WebhookPlatforms() []Platform {
+	buildersMu.RLock()
+	defer buildersMu.RUnlock()
+	return slices.Sorted(maps.Keys(builders))
+}
+
+// DetectPlatform guesses the platform from a webhook URL. It is only meant to
+// preselect the platform in the UI, an empty platform is returned when the URL
+// is not recognised.
+func DetectPlatform(url string) Platform {
+	url = strings.ToLower(url)
+
+	switch {
+	case strings.Contains(url, "discord"):
+		return Discord
+	case strings.Contains(url, "slack"):
+		return Slack
+	case strings.Contains(url, "teams") || strings.Contains(url, "office.com"):
+		return Teams
+	}
+	return ""
+}
+
 func levelColor(level Level) int {
 	switch level {
 	case Error:
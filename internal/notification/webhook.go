@@ -2,38 +2,40 @@ package notification
 
 import (
 	"bytes"
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 )
 
+// WebhookNotifier posts the payload of its builder to a URL. With a secret
+// the request carries X-Honk-Timestamp and X-Honk-Signature, the hex encoded
+// HMAC-SHA256 of the timestamp, a dot and the body, prefixed with "sha256=".
 type WebhookNotifier struct {
 	URL     string
+	Method  string
+	Headers map[string]string
+	Secret  string
 	Builder PayloadBuilder
 	Client  *http.Client
 }
 
-func NewWebhookNotifier(url string) *WebhookNotifier {
-	var builder PayloadBuilder
-
-	switch {
-	case strings.Contains(url, "discord"):
-		builder = NewDiscordBuilder()
-	case strings.Contains(url, "slack"):
-		builder = SlackBuilder{}
-	case strings.Contains(url, "teams"):
-		builder = TeamsBuilder{}
-	default:
-		panic("unsupported webhook platform")
+func NewWebhookNotifier(platform Platform, url string) (*WebhookNotifier, error) {
+	builder, err := NewBuilder(platform)
+	if err != nil {
+		return nil, err
 	}
 
 	return &WebhookNotifier{
 		URL:     url,
 		Builder: builder,
 		Client:  &http.Client{Timeout: 10 * time.Second},
-	}
+	}, nil
 }
 
 func (w *WebhookNotifier) Send(msg Message) error {
@@ -50,13 +52,26 @@ func (w *WebhookNotifier) Send(msg Message) error {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", w.URL, bytes.NewBuffer(payload))
+	req, err := http.NewRequest(cmp.Or(w.Method, http.MethodPost), w.URL, bytes.NewBuffer(payload))
 	if err != nil {
 		return err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "honk-notifier/1.0")
+	for name, value := range w.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if w.Secret != "" {
+		timestamp := strconv.FormatInt(msg.Timestamp.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(payload)
+
+		req.Header.Set("X-Honk-Timestamp", timestamp)
+		req.Header.Set("X-Honk-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
 
 	resp, err := w.Client.Do(req)
 	if err != nil {
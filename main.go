@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"honk/internal"
 	"honk/internal/api"
+	"honk/internal/auth"
+	"honk/internal/config"
 	"honk/internal/database"
 	"honk/internal/monitor"
+	"honk/internal/notification"
+	"os"
+	"time"
 )
 
 var (
+	log = internal.GetLogger()
+
 	version, commit, date string
 
 	//go:embed client/dist/*
@@ -15,22 +24,84 @@ var (
 )
 
 func main() {
-	db := database.Initialize()
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal("Invalid configuration: %v", err)
+	}
+	configureLogger(cfg.Log)
+
+	db := database.Initialize(cfg.DataDir, cfg.DatabaseDSN())
 
 	manager := monitor.NewManager(db)
+	manager.SetRetention(monitor.RetentionPolicy{
+		RawDays:    cfg.Retention.RawDays,
+		HourlyDays: cfg.Retention.HourlyDays,
+		DailyDays:  cfg.Retention.DailyDays,
+	})
+	manager.SetSMTP(notification.SMTPSettings{
+		Host:     cfg.SMTP.Host,
+		Port:     cfg.SMTP.Port,
+		Username: cfg.SMTP.Username,
+		Password: cfg.SMTP.Password,
+		From:     cfg.SMTP.From,
+		Security: notification.SMTPSecurity(cfg.SMTP.Security),
+		Timeout:  time.Duration(cfg.SMTP.Timeout),
+	})
+
+	authService := auth.NewService(db)
 	apiServer := api.API{
-		Authentication: false,
-		Dashboard:      false,
-		Port:           8080,
+		Authentication: cfg.Features.Authentication,
+		Dashboard:      cfg.Features.Dashboard,
+		Metrics:        cfg.Features.Metrics,
+		Address:        cfg.Listen,
+		CertFile:       cfg.TLS.CertFile,
+		KeyFile:        cfg.TLS.KeyFile,
 
 		Manager: manager,
+		Auth:    authService,
+	}
+
+	if apiServer.Authentication {
+		if err := authService.Bootstrap(cfg.Admin.Username, cfg.Admin.Password); err != nil {
+			log.Fatal("Failed to bootstrap admin user: %v", err)
+		}
 	}
 	errorChan := make(chan struct{}, 1)
 
-	manager.RegisterHandler(database.ConnectionTypeHTTP, monitor.NewHTTPPingHandler())
-	manager.RegisterHandler(database.ConnectionTypePing, monitor.NewICMPPingHandler(5))
-	manager.RegisterHandler(database.ConnectionTypeTCP, monitor.NewTCPPingHandler(5))
+	timeouts := cfg.Timeouts
+	manager.RegisterHandler(database.ConnectionTypeHTTP, monitor.NewHTTPPingHandler(time.Duration(timeouts.HTTP)))
+	manager.RegisterHandler(database.ConnectionTypePing, monitor.NewICMPPingHandler(time.Duration(timeouts.ICMP)))
+	manager.RegisterHandler(database.ConnectionTypeTCP, monitor.NewTCPPingHandler(time.Duration(timeouts.TCP)))
+	manager.RegisterHandler(database.ConnectionTypeTLS, monitor.NewTLSHandler(time.Duration(timeouts.TLS)))
+	manager.RegisterHandler(database.ConnectionTypeDNS, monitor.NewDNSHandler(time.Duration(timeouts.DNS)))
+	manager.RegisterHandler(database.ConnectionTypePush, monitor.NewPushHandler())
+	if cfg.Features.Containers {
+		registerContainerHandler(manager, time.Duration(timeouts.Container))
+	}
 	manager.Start()
+	if cfg.MonitorsFile != "" {
+		manager.SyncFile(cfg.MonitorsFile)
+	}
 
 	apiServer.Start(content, errorChan, version, commit, date)
 }
+
+func configureLogger(cfg config.LogConfig) {
+	// The level is validated when the config is loaded
+	level, _ := internal.ParseLogLevel(cfg.Level)
+	log.SetLevel(level)
+	log.SetJSON(cfg.Format == "json")
+}
+
+func registerContainerHandler(manager *monitor.Manager, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	handler, err := monitor.NewContainerHandler(ctx, timeout)
+	if err != nil {
+		log.Warning("Container monitors are disabled: %v", err)
+		return
+	}
+
+	manager.RegisterHandler(database.ConnectionTypeContainer, handler)
+}